@@ -1,10 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2/dialog"
@@ -49,14 +50,14 @@ func (h *homeScreen) renameChPress() {
 }
 
 func (h *homeScreen) fadeToPress() {
-	// Get the float32 value of fadeTo field
-	target, err := strconv.ParseFloat(h.fadeTo.entry.Text, 32)
-	targetF := float32(target)
+	// Get the fader value of the fadeTo field
+	//     given in dB, e.g. -10dB, or as a raw 0.00 to 1.00
+	target, err := parseLevel(h.fadeTo.entry.Text)
 	if err != nil {
 		h.console.log(err.Error())
 		return
 	}
-	go h.fade(targetF)
+	go h.fade(target)
 }
 
 func (h *homeScreen) fadeOutPress() {
@@ -83,19 +84,53 @@ func (h *homeScreen) connectPress() {
 	entry := widget.NewEntry()
 	entry.SetText(App.Preferences().String("RHost"))
 	entry.SetPlaceHolder("Set remote ip address")
+	// Offer the consoles found on the network
+	//     Picking one fills in its ip address
+	//   The search fills hosts in while the dialog is open, hence the lock
+	var hostsMu sync.Mutex
+	hosts := make(map[string]string)
+	picklist := widget.NewSelect(nil, func(picked string) {
+		hostsMu.Lock()
+		host, ok := hosts[picked]
+		hostsMu.Unlock()
+		if ok {
+			entry.SetText(host)
+		}
+	})
+	picklist.PlaceHolder = "Searching..."
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), discoverTimeout)
+		defer cancel()
+		consoles, err := discoverConsoles(ctx, broadcastAddresses(h.mixer.remotePort))
+		if err != nil || len(consoles) == 0 {
+			picklist.PlaceHolder = "No consoles found"
+			picklist.Refresh()
+			return
+		}
+		options := make([]string, len(consoles))
+		hostsMu.Lock()
+		for i, console := range consoles {
+			options[i] = console.String()
+			hosts[options[i]] = console.host()
+		}
+		hostsMu.Unlock()
+		picklist.PlaceHolder = "Select a console"
+		picklist.SetOptions(options)
+	}()
 	// Show Dialog
 	dialog.ShowForm(
 		"Connect to Mixing Console",
 		"Confirm",
 		"Cancel",
 		[]*widget.FormItem{
+			{Text: "Found", Widget: picklist},
 			{Text: "IP Address", Widget: entry},
 		},
 		func(confirmConnect bool) {
 			if confirmConnect {
 				go func() {
-					// Close the current Conn if it exists
-					closeConnIfExists(h.mixer.conn)
+					// Close the current client if it exists
+					h.mixer.disconnect()
 
 					// Get ip address from entry
 					rhost := entry.Text
@@ -112,7 +147,6 @@ func (h *homeScreen) connectPress() {
 					// Make the connection
 					err := h.mixer.connect()
 					if err != nil {
-						h.mixer.conn = nil
 						h.console.log(err.Error())
 						return
 					}
@@ -124,7 +158,7 @@ func (h *homeScreen) connectPress() {
 					doneSignal <- true
 					h.console.log("clr")
 					if err != nil {
-						h.mixer.conn = nil
+						h.mixer.disconnect()
 						h.console.log("bad connection")
 						h.console.log(err.Error())
 						return
@@ -133,6 +167,8 @@ func (h *homeScreen) connectPress() {
 					h.status.SetText(strings.Join(ss, " "))
 					// Start levelMonitor
 					go h.mixer.monitorLevels(h.levelLabel.SetText)
+					// Show the mutes as they change
+					go h.watchMutes()
 					// Rename buttons
 					h.renameChButtons()
 				}()
@@ -142,18 +178,83 @@ func (h *homeScreen) connectPress() {
 }
 
 func (h *homeScreen) renameChButtons() {
+	// Request every name at once
+	//     Names whose node did not arrive are asked for one at a time,
+	//     and buttons whose name still did not arrive keep their label
+	channelIDs := make([]int, len(h.channelBank))
+	for i := range h.channelBank {
+		channelIDs[i] = i
+	}
+	names, _ := h.mixer.getNames(channelIDs...)
 	for i, button := range h.channelBank {
-		name, err := h.mixer.getName(i)
-		if err != nil {
-			continue
+		name, ok := names[i]
+		if !ok {
+			var err error
+			name, err = h.mixer.getName(i)
+			if err != nil {
+				continue
+			}
 		}
 
 		button.SetText(name)
 	}
 }
 
+func (h *homeScreen) mutePress(muted bool) {
+	// Ignore the change when it only shows the console's state
+	ch := h.mixer.selectedCh
+	if current, err := h.mixer.isMuted(ch); err == nil && current == muted {
+		return
+	}
+	go func() {
+		err := h.mixer.setMute(ch, muted)
+		if err != nil {
+			h.console.log(err.Error())
+		}
+	}()
+}
+
+func (h *homeScreen) muteGroupPress(group int, on bool) {
+	if current, err := h.mixer.isMuteGroupOn(group); err == nil && current == on {
+		return
+	}
+	go func() {
+		err := h.mixer.setMuteGroup(group, on)
+		if err != nil {
+			h.console.log(err.Error())
+		}
+	}()
+}
+
+func (h *homeScreen) watchMutes() {
+	// Keeps the mute toggles showing the console's state
+	//     The values come from the mirror, so this asks the console
+	//     only for what it has not yet told us
+	//   Stops when the connection is closed
+	client := h.mixer.client
+	if client == nil {
+		return
+	}
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-client.Done():
+			return
+		case <-ticker.C:
+		}
+		if muted, err := h.mixer.isMuted(h.mixer.selectedCh); err == nil && muted != h.muteCheck.Checked {
+			h.muteCheck.SetChecked(muted)
+		}
+		for i, check := range h.muteGroups {
+			if on, err := h.mixer.isMuteGroupOn(i + 1); err == nil && on != check.Checked {
+				check.SetChecked(on)
+			}
+		}
+	}
+}
+
 func (h *homeScreen) closeAppPress() {
-	h.mixer.monitor.conn.Close()
-	h.mixer.conn.Close()
+	h.mixer.disconnect()
 	os.Exit(1)
 }
@@ -0,0 +1,79 @@
+// Command x32replay re-sends a session recorded by x32app
+// to a console or a simulator, or exports it as a pcap capture.
+//
+//	X32APP_RECORD=show.jsonl x32app
+//	x32replay -to 192.168.1.50:10023 show.jsonl
+//	x32replay -pcap show.pcap show.jsonl
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+func main() {
+	to := flag.String("to", "127.0.0.1:10023", "address to send the session to, ip:port")
+	localPort := flag.Int("port", 0, "local UDP port to send from, any free port if 0")
+	speed := flag.Float64("speed", 1, "playback speed, 0 sends every packet without waiting")
+	recv := flag.Bool("recv", false, "send the packets the app received rather than those it sent")
+	pcap := flag.String("pcap", "", "write the session to this pcap file instead of sending it")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: x32replay [flags] session.jsonl\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := run(flag.Arg(0), *to, *localPort, *speed, *recv, *pcap)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "x32replay:", err)
+		os.Exit(1)
+	}
+}
+
+func run(path, to string, localPort int, speed float64, recv bool, pcap string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	records, err := osc.ReadSession(f)
+	if err != nil {
+		return err
+	}
+
+	if pcap != "" {
+		out, err := os.Create(pcap)
+		if err != nil {
+			return err
+		}
+		err = osc.WritePcap(out, records)
+		if err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	}
+
+	conn, err := osc.Dial(localPort, to)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	opts := osc.ReplayOptions{Speed: speed, Direction: osc.DirSend}
+	if recv {
+		opts.Direction = osc.DirRecv
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return osc.Replay(ctx, conn, records, opts)
+}
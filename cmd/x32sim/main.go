@@ -0,0 +1,37 @@
+// Command x32sim runs a simulated X32 console for development
+// without the hardware.
+//
+//	x32sim -addr 127.0.0.1:10023
+//
+// then connect x32app, or X32 tools, to that address
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/grogersstephen/x32app/x32sim"
+)
+
+func main() {
+	addr := flag.String("addr", ":10023", "UDP address to listen on, ip:port")
+	name := flag.String("name", "x32sim", "console name reported to /xinfo")
+	flag.Parse()
+
+	console := x32sim.New()
+	console.Name = *name
+	err := console.Start(*addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "x32sim:", err)
+		os.Exit(1)
+	}
+	fmt.Println("x32sim: listening on", console.Addr())
+
+	// Run until interrupted
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+	console.Close()
+}
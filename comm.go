@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"net"
+	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/grogersstephen/x32app/osc"
@@ -18,13 +22,15 @@ type mixer struct {
 	faders          []*fader
 	selectedCh      int
 	faderResolution float32
-	conn            net.Conn
+	client          *osc.Client // shared by fades, the level monitor and renames
 	monitor         *levelMonitor
+	meters          *meterState
+	recorder        *osc.Recorder // when set, all traffic with the console is recorded
+	mirror          *consoleMirror
+	stopRemote      func()
 }
 
 type levelMonitor struct {
-	localPort int
-	conn      net.Conn
 	updatedAt time.Time
 }
 
@@ -32,8 +38,17 @@ type fader struct {
 	name      string
 	channel   int
 	channelID int
-	active    bool // in active motion?
-	level     float32
+	active    atomic.Bool   // in active motion? cleared by killSwitch while a fade runs
+	level     atomic.Uint32 // bits of the last known level, set from the read loop
+	streamed  atomic.Int64  // unix nanoseconds of the last level pushed by a subscription
+	sends     []*fader      // one for each mix bus or matrix send, to fade them
+}
+
+// Replies from the console normally arrive within a few milliseconds,
+// so a lost UDP packet is retried quickly rather than waited on
+var inquireOptions = osc.InquireOptions{
+	Timeout: 250 * time.Millisecond,
+	Retries: 2,
 }
 
 func newX32() *mixer {
@@ -57,12 +72,14 @@ func newX32() *mixer {
 		faders:          make([]*fader, faderCount),
 		selectedCh:      0,
 		faderResolution: 1024,
-		conn:            nil,
+		client:          nil,
 		monitor: &levelMonitor{
-			localPort: 10024,
-			conn:      nil,
 			updatedAt: time.Now(),
 		},
+		meters: &meterState{
+			channels: make([]channelMeter, faderCount),
+		},
+		mirror: newConsoleMirror(),
 	}
 	channelIDMap := map[int]string{
 		0:  "channel",
@@ -87,8 +104,13 @@ func newX32() *mixer {
 			channelID: i,
 			name:      name,
 			channel:   channel,
-			active:    false,
-			level:     0,
+		}
+		for send := 1; send <= sendCount(i); send++ {
+			m.faders[i].sends = append(m.faders[i].sends, &fader{
+				channelID: i,
+				name:      "send",
+				channel:   send,
+			})
 		}
 		channel++
 	}
@@ -117,22 +139,40 @@ func establishConnection(localPort int, remoteAddr string, tries int) (conn net.
 func (m *mixer) monitorLevels(levelLog func(s string)) {
 	// This keeps up with the level of the currently selected channel
 	//     Updates msg with label of the channel and its level
-	// Create a new connection just for the level monitor
-	// Wait until we start a main connection
-	var err error
-	m.monitor.conn, err = establishConnection(
-		m.monitor.localPort,
-		fmt.Sprintf("%s:%d", m.remoteHost, m.remotePort),
-		5)
-	// If a meaningful connection could not be made, abort the monitor
-	if err != nil {
+	// The monitor shares the mixer's client
+	//     and stops when that client is closed
+	client := m.client
+	if client == nil {
 		return
 	}
+	// Stream real signal levels alongside the fader position
+	//     Without meters the fader position is still shown
+	if stopMeters, err := m.startMeters(client); err == nil {
+		defer stopMeters()
+	}
+	// Have the console push fader levels rather than asking for them
+	//     A level is asked for whenever none has been pushed within
+	//     a renewal interval, as a console may ignore the subscription
+	if stopFaders, err := m.watchFaders(client); err == nil {
+		defer stopFaders()
+	}
+	timeout := renewInterval
 	for {
+		select {
+		case <-client.Done():
+			return
+		default:
+		}
 		fmt.Printf("m.selectedCh: %v\n", m.selectedCh)
-		// the following getLevel() is blocking, should be on goroutine?
-		m.faders[m.selectedCh].getLevel(m.monitor.conn)
-		levelLog(m.faders[m.selectedCh].levelMessage())
+		ch := m.selectedCh
+		if !m.faders[ch].streamedWithin(timeout) {
+			m.getLevel(ch)
+		}
+		msg := m.faders[ch].levelMessage()
+		if meter, ok := m.getMeter(ch); ok {
+			msg = fmt.Sprintf("%s  meter %s", msg, meter)
+		}
+		levelLog(msg)
 		m.monitor.updatedAt = time.Now()
 		time.Sleep(42 * time.Millisecond) // a 41.6667ms interval is equivalent to 24hz
 	}
@@ -153,17 +193,118 @@ func (m *mixer) setFaderResolution(newValue string) error {
 	return nil
 }
 
-func (m *mixer) connect() (err error) {
-	m.conn, err = establishConnection(
+func (m *mixer) connect() error {
+	conn, err := establishConnection(
 		m.localPort,
 		fmt.Sprintf("%s:%d", m.remoteHost, m.remotePort),
 		5)
-	return err
+	if err != nil {
+		return err
+	}
+	if m.recorder != nil {
+		conn = osc.RecordConn(conn, m.recorder)
+	}
+	conn = &mirrorConn{Conn: conn, mirror: m.mirror}
+	m.client = osc.NewClient(conn)
+	// Keep the mirror current with changes made elsewhere
+	m.stopRemote = m.startRemote(m.client)
+	return nil
+}
+
+func (m *mixer) startRecording(path string) error {
+	// Record every packet sent to and received from the console
+	//     to the file at path, appending to any earlier session
+	//   Takes effect from the next connection
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot record session: %w", err)
+	}
+	m.recorder = osc.NewRecorder(f)
+	return nil
+}
+
+func (m *mixer) disconnect() {
+	// Close the current client if it exists
+	if m.stopRemote != nil {
+		m.stopRemote()
+		m.stopRemote = nil
+	}
+	if m.client != nil {
+		m.client.Close()
+		m.client = nil
+	}
+}
+
+func (m *mixer) send(msg osc.Message) error {
+	if m.client == nil {
+		return fmt.Errorf("no connection made")
+	}
+	return m.client.Send(msg)
+}
+
+func inquire(client *osc.Client, msg osc.Message) (reply osc.Message, err error) {
+	// Check that the client is not nil
+	if client == nil {
+		return reply, fmt.Errorf("no connection made")
+	}
+	return client.Inquire(context.Background(), msg, inquireOptions)
+}
+
+func (m *mixer) query(address string) (reply osc.Message, err error) {
+	// Returns the current value at the given address
+	//     Read from the mirror when /xremote is keeping it current,
+	//     otherwise ask the console and remember the answer
+	if reply, ok := m.mirror.get(address); ok {
+		return reply, nil
+	}
+	reply, err = inquire(m.client, osc.NewMessage(address))
+	if err != nil {
+		return reply, err
+	}
+	m.mirror.apply(reply)
+	return reply, nil
+}
+
+func (m *mixer) queryFloat(address string) (float32, error) {
+	reply, err := m.query(address)
+	if err != nil {
+		return 0, err
+	}
+	v, err := reply.ArgFloat(0)
+	if err != nil {
+		return 0, fmt.Errorf("could not get %s: %w", address, err)
+	}
+	return v, nil
+}
+
+func (m *mixer) queryInt(address string) (int32, error) {
+	reply, err := m.query(address)
+	if err != nil {
+		return 0, err
+	}
+	v, err := reply.ArgInt(0)
+	if err != nil {
+		return 0, fmt.Errorf("could not get %s: %w", address, err)
+	}
+	return v, nil
+}
+
+func (m *mixer) sendLinked(channelID int, pathOf func(id int) string, addValue func(msg *osc.Message)) error {
+	// Writes the parameter at pathOf(id) on the channel and its linked partner
+	for _, id := range m.withPartner(channelID) {
+		msg := osc.NewMessage(pathOf(id))
+		addValue(&msg)
+		err := m.send(msg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (m *mixer) getStatus() (status []string, err error) {
 	msg := osc.NewMessage("/info")
-	reply, err := osc.Inquire(m.conn, msg)
+	reply, err := inquire(m.client, msg)
 	if err != nil {
 		return status, err
 	}
@@ -180,26 +321,26 @@ func (m *mixer) getStatus() (status []string, err error) {
 func (m *mixer) getName(ch int) (string, error) {
 	// Get the OSC method for the name of the channel
 	namePath := getNamePath(ch)
-	// Create the OSC message
-	msg := osc.NewMessage(namePath)
-	// Make the inquiry
-	reply, err := osc.Inquire(m.conn, msg)
+	// Make the inquiry, or read it from the mirror
+	reply, err := m.query(namePath)
 	if err != nil {
 		return "", err
 	}
-	s, ok := reply.Arguments[0].Decoded.(string)
-	if !ok {
-		return "", fmt.Errorf("cannot get name")
+	s, err := reply.ArgString(0)
+	if err != nil {
+		return "", fmt.Errorf("cannot get name: %w", err)
 	}
 	return s, nil
 }
 
 func (m *mixer) setName(ch int, name string) error {
-	namePath := getNamePath(ch)
-	msg := osc.NewMessage(namePath)
-	msg.AddString(name)
-	err := osc.Send(m.conn, msg)
-	return err
+	// Writes the name as the first value of the channel's config node
+	//     The rest of the node is left as it is
+	path := getConfigNodePath(ch)
+	if path == "" {
+		return fmt.Errorf("channelID %d has no name", ch)
+	}
+	return m.setNode(consoleNode{path: path, values: []string{name}})
 }
 
 func (m *mixer) isInMotion(channelID int) bool {
@@ -207,15 +348,20 @@ func (m *mixer) isInMotion(channelID int) bool {
 	//     This test will return true even if another source is causing the motion
 	interval := 100 * time.Millisecond
 
+	// The mirror knows when the fader last moved without asking twice
+	if changed, ok := m.mirror.changedWithin(getFaderPath(channelID), interval); ok {
+		return changed
+	}
+
 	// Test fader level twice
-	levelBefore, err := m.faders[channelID].getLevel(m.conn)
+	levelBefore, err := m.getLevel(channelID)
 	if err != nil {
 		return true // If the request fails, report fader to be in motion
 	}
 	// Sleep
 	time.Sleep(interval)
 	// Test fader level again
-	levelAfter, err := m.faders[channelID].getLevel(m.conn)
+	levelAfter, err := m.getLevel(channelID)
 	if err != nil {
 		return true
 	}
@@ -232,78 +378,71 @@ func (m *mixer) killSwitch(channelIDs ...int) {
 	// Send enough kill signals to receive at all 'fader motion' goroutines
 	for _, id := range channelIDs {
 		m.faders[id].deactivate()
+		// Stop fades of the channel's sends too
+		for _, send := range m.faders[id].sends {
+			send.deactivate()
+		}
 	}
 }
 
-func (f *fader) activate() {
-	f.active = true
+func (f *fader) claim() bool {
+	// Marks the fader active for a fade, unless a fade already holds it
+	return f.active.CompareAndSwap(false, true)
 }
 func (f *fader) deactivate() {
-	f.active = false
+	f.active.Store(false)
 }
 
-func (f *fader) getLevel(conn net.Conn) (level float32, err error) {
-	level, err = getFaderLevel(f.channelID, conn)
-	if err != nil {
-		return level, err
-	}
-
-	// Assign the level
-	f.level = level
+func (f *fader) setLevel(level float32) {
+	f.level.Store(math.Float32bits(level))
+}
+func (f *fader) lastLevel() float32 {
+	return math.Float32frombits(f.level.Load())
+}
 
-	return level, nil
+func (f *fader) streamLevel(level float32) {
+	// Sets a level pushed by a subscription
+	f.setLevel(level)
+	f.streamed.Store(time.Now().UnixNano())
+}
+func (f *fader) streamedWithin(d time.Duration) bool {
+	return time.Since(time.Unix(0, f.streamed.Load())) < d
 }
-func getFaderLevel(channelID int, conn net.Conn) (level float32, err error) {
-	// Return the level of the given channel's fader
-	// Check that the connection is not nil
-	if conn == nil {
-		return level, fmt.Errorf("no connection made")
-	}
 
-	// Send the message
-	msg := osc.NewMessage(getFaderPath(channelID))
-	reply, err := osc.Inquire(conn, msg)
+func (m *mixer) getLevel(channelID int) (level float32, err error) {
+	// Return the level of the given channel's fader
+	//     and assign it to the fader
+	reply, err := m.query(getFaderPath(channelID))
 	if err != nil {
 		return level, err
 	}
 
 	// Type check the first argument
-	level, ok := reply.Arguments[0].Decoded.(float32)
-	if !ok {
-		return level, fmt.Errorf("could not get fader channelID %d level", channelID)
+	level, err = reply.ArgFloat(0)
+	if err != nil {
+		return level, fmt.Errorf("could not get fader channelID %d level: %w", channelID, err)
 	}
 
+	// Assign the level
+	m.faders[channelID].setLevel(level)
+
 	return level, nil
 }
-func (f *fader) subLevel(conn net.Conn, levelOut func(s string)) error {
-	// This will only be valid for 10 seconds
-	// Check that the conn is not nil
-	if conn == nil {
-		return fmt.Errorf("no connection made")
-	}
-	// Make message to send
-	msg := osc.NewMessage("/subscribe")
-	msg.AddString(getFaderPath(f.channelID))
-	// Send the message
-	for {
-		reply, err := osc.Listen(conn)
+func (f *fader) subLevel(client *osc.Client, levelOut func(s string)) (cancel func(), err error) {
+	// Streams the level of the fader to levelOut until cancelled
+	//     The subscription is renewed before its 10 second expiry
+	s, err := subscribe(client, getFaderPath(f.channelID), 1, func(reply osc.Message) {
+		level, err := reply.ArgFloat(0)
 		if err != nil {
-			return err
-		}
-		level, ok := reply.Arguments[0].Decoded.(float32)
-		if !ok {
-			return fmt.Errorf("incoming value cannot be parsed as float")
+			return
 		}
 		// Assign the level
-		f.level = level
+		f.setLevel(level)
 		//
-		levelOut(fmt.Sprintf("%.2f", f.level))
-	}
-}
-
-func closeConnIfExists(conn net.Conn) {
-	if conn != nil {
-		conn.Close()
-		conn = nil
+		levelOut(fmt.Sprintf("%.2f", level))
+	})
+	if err != nil {
+		return nil, err
 	}
+	return s.cancel, nil
 }
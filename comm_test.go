@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+	"github.com/grogersstephen/x32app/x32sim"
+)
+
+func newTestMixer(t *testing.T) (*mixer, *x32sim.Console) {
+	// Returns a mixer connected to a simulated console on loopback
+	return connectTestMixer(t, x32sim.New())
+}
+
+func connectTestMixer(t *testing.T, console *x32sim.Console) (*mixer, *x32sim.Console) {
+	// Starts the console, so it can be set up first, and connects to it
+	if err := console.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { console.Close() })
+
+	m := newX32()
+	m.remoteHost = "127.0.0.1"
+	m.remotePort = portOf(t, console.Addr())
+	m.localPort = 0
+	if err := m.connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.disconnect)
+	return m, console
+}
+
+func portOf(t *testing.T, addr string) int {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return udpAddr.Port
+}
+
+func consoleFloat(t *testing.T, console *x32sim.Console, address string) float32 {
+	t.Helper()
+	msg, ok := console.Get(address)
+	if !ok {
+		t.Fatalf("console has no %s", address)
+	}
+	v, err := msg.ArgFloat(0)
+	if err != nil {
+		t.Fatalf("%s: %v", address, err)
+	}
+	return v
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	// Waits up to a second for cond, as UDP delivery is asynchronous
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	m, _ := newTestMixer(t)
+	status, err := m.getStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 4 || status[2] != "X32" {
+		t.Errorf("status = %q", status)
+	}
+}
+
+func TestNames(t *testing.T) {
+	m, _ := newTestMixer(t)
+	if err := m.setName(3, "Snare"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.setName(72, "Drums"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "rename", func() bool {
+		name, err := m.getName(3)
+		return err == nil && name == "Snare"
+	})
+
+	names, err := m.getNames(0, 3, 72)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{0: "", 3: "Snare", 72: "Drums"}
+	for id, name := range want {
+		if names[id] != name {
+			t.Errorf("name of %d = %q, want %q", id, names[id], name)
+		}
+	}
+}
+
+func TestGetLevel(t *testing.T) {
+	m, console := newTestMixer(t)
+	level, err := m.getLevel(70)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level != 0.75 || m.faders[70].lastLevel() != 0.75 {
+		t.Errorf("level = %v, fader.level = %v; want 0.75", level, m.faders[70].lastLevel())
+	}
+
+	// A change on the console surface reaches the mirror through /xremote
+	msg := osc.NewMessage("/main/st/mix/fader")
+	msg.AddFloat(0.5)
+	console.Set(msg)
+	eventually(t, "mirror update", func() bool {
+		level, err := m.getLevel(70)
+		return err == nil && level == 0.5
+	})
+}
+
+func TestFadeTo(t *testing.T) {
+	m, console := newTestMixer(t)
+	start := time.Now()
+	if err := m.fadeTo(0, 0.25, 200*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("fade took %v, want at least 200ms", elapsed)
+	}
+	eventually(t, "fade to reach the console", func() bool {
+		level := consoleFloat(t, console, "/ch/01/mix/fader")
+		return math.Abs(float64(level-0.25)) <= 1/float64(m.faderResolution)
+	})
+	// The mirror holds what was sent
+	level, err := m.getLevel(0)
+	if err != nil || math.Abs(float64(level-0.25)) > 1/float64(m.faderResolution) {
+		t.Errorf("mirrored level = %v, %v; want 0.25", level, err)
+	}
+}
+
+func TestFadeInterrupted(t *testing.T) {
+	m, console := newTestMixer(t)
+	done := make(chan error, 1)
+	go func() {
+		done <- m.fadeTo(1, 0, 2*time.Second)
+	}()
+	eventually(t, "fade to start", func() bool { return m.faders[1].active.Load() })
+	m.killSwitch(1)
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("fade was not interrupted")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("fade did not stop")
+	}
+	if level := consoleFloat(t, console, "/ch/02/mix/fader"); level == 0 {
+		t.Error("fade ran to the end")
+	}
+}
+
+func TestUnitToFaderValue(t *testing.T) {
+	for _, tc := range []struct {
+		u    float32
+		want int
+	}{
+		{0, 0},
+		{0.5, 512},
+		{1, 1024},
+	} {
+		if got, err := unitToFaderValue(tc.u, 1024); err != nil || got != tc.want {
+			t.Errorf("unitToFaderValue(%v) = %v, %v; want %v", tc.u, got, err, tc.want)
+		}
+	}
+	nan := float32(math.NaN())
+	inf := float32(math.Inf(1))
+	for _, u := range []float32{-0.1, 1.1, nan, inf, -inf} {
+		if _, err := unitToFaderValue(u, 1024); err == nil {
+			t.Errorf("unitToFaderValue(%v) did not fail", u)
+		}
+	}
+}
+
+func TestIsInMotion(t *testing.T) {
+	m, console := newTestMixer(t)
+	if m.isInMotion(5) {
+		t.Error("resting fader reported in motion")
+	}
+	msg := osc.NewMessage("/ch/06/mix/fader")
+	msg.AddFloat(0.1)
+	console.Set(msg)
+	eventually(t, "change to arrive", func() bool {
+		level, _ := m.getLevel(5)
+		return level == 0.1
+	})
+	if !m.isInMotion(5) {
+		t.Error("fader moved on the console not reported in motion")
+	}
+}
+
+func TestDiscoverSimulator(t *testing.T) {
+	console := x32sim.New()
+	console.Name = "Desk"
+	if err := console.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer console.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	consoles, err := discoverConsoles(ctx, []string{console.Addr()})
+	if err != nil || len(consoles) != 1 || consoles[0].name != "Desk" {
+		t.Fatalf("got %v, %v; want the simulator", consoles, err)
+	}
+}
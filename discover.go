@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// How long to wait for consoles to answer a discovery broadcast
+const discoverTimeout = 750 * time.Millisecond
+
+// consoleInfo describes a console which answered /xinfo
+type consoleInfo struct {
+	addr     string // where the reply came from, "ip:port"
+	ip       string // as reported by the console
+	name     string
+	model    string
+	firmware string
+}
+
+func (ci consoleInfo) String() string {
+	// e.g. "FOH X32 (X32 4.06) 192.168.1.20"
+	return fmt.Sprintf("%s (%s %s) %s", ci.name, ci.model, ci.firmware, ci.host())
+}
+
+func (ci consoleInfo) host() string {
+	// Returns the ip to connect to
+	//     The reported ip is used only if the reply's source is unknown
+	host, _, err := net.SplitHostPort(ci.addr)
+	if err != nil {
+		return ci.ip
+	}
+	return host
+}
+
+func broadcastAddresses(port int) []string {
+	// Returns the limited broadcast address and the broadcast address
+	//     of every IPv4 network this machine is on
+	//   Some routers and operating systems only pass one of the two
+	addrs := []string{fmt.Sprintf("255.255.255.255:%d", port)}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return addrs
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagBroadcast == 0 {
+			continue
+		}
+		ifAddrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range ifAddrs {
+			ipNet, ok := a.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil {
+				continue
+			}
+			ip := ipNet.IP.To4()
+			mask := net.IP(ipNet.Mask).To4()
+			if mask == nil {
+				continue
+			}
+			broadcast := make(net.IP, 4)
+			for i := range broadcast {
+				broadcast[i] = ip[i] | ^mask[i]
+			}
+			addrs = append(addrs, net.JoinHostPort(broadcast.String(), fmt.Sprint(port)))
+		}
+	}
+	return addrs
+}
+
+func discoverConsoles(ctx context.Context, targets []string) (consoles []consoleInfo, err error) {
+	// Sends /xinfo to every target and collects the consoles which answer
+	//     until ctx is done
+	//   targets are usually broadcastAddresses(10023),
+	//     but can be any "ip:port", such as a console simulator on loopback
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	request := osc.NewMessage("/xinfo")
+	packet, err := request.AppendPacket(nil)
+	if err != nil {
+		return nil, err
+	}
+	sent := 0
+	for _, target := range targets {
+		addr, err := net.ResolveUDPAddr("udp4", target)
+		if err != nil {
+			continue
+		}
+		if _, err := conn.WriteTo(packet, addr); err == nil {
+			sent++
+		}
+	}
+	if sent == 0 {
+		return nil, fmt.Errorf("cannot send discovery request")
+	}
+
+	seen := make(map[string]bool)
+	buf := make([]byte, osc.MaxPacketSize)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			break
+		}
+		if err != nil {
+			return consoles, err
+		}
+		var reply osc.Message
+		if err := reply.ParseView(buf[:n]); err != nil || string(reply.Address) != "/xinfo" {
+			continue
+		}
+		if seen[from.String()] {
+			// Answered more than one broadcast address
+			continue
+		}
+		info := consoleInfo{addr: from.String()}
+		// ,ssss ip, name, model, firmware
+		fields := []*string{&info.ip, &info.name, &info.model, &info.firmware}
+		for i, field := range fields {
+			if s, err := reply.ArgString(i); err == nil {
+				*field = s
+			}
+		}
+		seen[from.String()] = true
+		consoles = append(consoles, info)
+	}
+
+	sort.Slice(consoles, func(i, j int) bool {
+		return consoles[i].name < consoles[j].name
+	})
+	return consoles, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+func startXinfoResponder(t *testing.T, name string) string {
+	// Answers /xinfo on a loopback port as a console would
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &osc.Server{
+		Handler: osc.PacketHandlerFunc(func(p osc.Packet, from osc.Peer) {
+			msg, ok := p.(*osc.Message)
+			if !ok || string(msg.Address) != "/xinfo" {
+				return
+			}
+			reply := osc.NewMessage("/xinfo")
+			reply.AddString("127.0.0.1")
+			reply.AddString(name)
+			reply.AddString("X32")
+			reply.AddString("4.06")
+			from.Send(reply)
+		}),
+	}
+	go s.Serve(conn)
+	t.Cleanup(func() { s.Close() })
+	return conn.LocalAddr().String()
+}
+
+func TestDiscoverConsoles(t *testing.T) {
+	foh := startXinfoResponder(t, "FOH")
+	mon := startXinfoResponder(t, "MON")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	// foh is listed twice, as a console on two broadcast addresses would be
+	consoles, err := discoverConsoles(ctx, []string{foh, mon, foh})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(consoles) != 2 {
+		t.Fatalf("found %d consoles, want 2: %v", len(consoles), consoles)
+	}
+	want := []consoleInfo{
+		{addr: foh, ip: "127.0.0.1", name: "FOH", model: "X32", firmware: "4.06"},
+		{addr: mon, ip: "127.0.0.1", name: "MON", model: "X32", firmware: "4.06"},
+	}
+	for i := range want {
+		if consoles[i] != want[i] {
+			t.Errorf("console %d = %+v, want %+v", i, consoles[i], want[i])
+		}
+	}
+	if got := consoles[0].host(); got != "127.0.0.1" {
+		t.Errorf("host() = %q, want 127.0.0.1", got)
+	}
+}
+
+func TestDiscoverConsolesNoReply(t *testing.T) {
+	// A port nobody answers on gives no consoles and no error
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	consoles, err := discoverConsoles(ctx, []string{conn.LocalAddr().String()})
+	if err != nil || len(consoles) != 0 {
+		t.Fatalf("got %v, %v; want no consoles", consoles, err)
+	}
+}
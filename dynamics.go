@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// Each of the 32 channels has a gate, /ch/01/gate/..., and a compressor
+// or expander, /ch/01/dyn/.... The console sends most values as a float
+// from 0 to 1 in equal steps, on a linear or logarithmic scale
+type paramScale struct {
+	min   float64
+	max   float64
+	steps int // number of steps from min to max
+	log   bool
+}
+
+var (
+	gateThresholdScale = paramScale{min: -80, max: 0, steps: 160}
+	gateRangeScale     = paramScale{min: 3, max: 60, steps: 57}
+	dynThresholdScale  = paramScale{min: -60, max: 0, steps: 120}
+	dynKneeScale       = paramScale{min: 0, max: 5, steps: 5}
+	dynGainScale       = paramScale{min: 0, max: 24, steps: 48}
+	dynMixScale        = paramScale{min: 0, max: 100, steps: 20}
+	attackScale        = paramScale{min: 0, max: 120, steps: 120}
+	holdScale          = paramScale{min: 0.02, max: 2000, steps: 100, log: true}
+	releaseScale       = paramScale{min: 5, max: 4000, steps: 100, log: true}
+)
+
+func (s paramScale) toFloat(v float64) float32 {
+	// Returns the console's float for v, rounded to the nearest step
+	if s.log {
+		return quantize(math.Log(v/s.min)/math.Log(s.max/s.min), s.steps)
+	}
+	return quantize((v-s.min)/(s.max-s.min), s.steps)
+}
+
+func (s paramScale) fromFloat(f float32) float64 {
+	// Counted in whole steps so that values come back exactly
+	step := math.Round(float64(f) * float64(s.steps))
+	if s.log {
+		return s.min * math.Pow(s.max/s.min, step/float64(s.steps))
+	}
+	return s.min + step*(s.max-s.min)/float64(s.steps)
+}
+
+func (s paramScale) check(name string, v float64) error {
+	if math.IsNaN(v) || v < s.min || v > s.max {
+		return fmt.Errorf("%s %v outside %v to %v", name, v, s.min, s.max)
+	}
+	return nil
+}
+
+// Gate modes, /ch/NN/gate/mode
+const (
+	gateExp2 = 0 // expander with a 1:2 ratio
+	gateExp3 = 1
+	gateExp4 = 2
+	gateGate = 3
+	gateDuck = 4
+)
+
+// Dynamics modes, /ch/NN/dyn/mode
+const (
+	dynComp = 0
+	dynExp  = 1
+)
+
+// The compressor ratio is chosen from a list, /ch/NN/dyn/ratio is its index
+var dynRatios = []float64{1.1, 1.3, 1.5, 2, 2.5, 3, 4, 5, 7, 10, 20, 100}
+
+// Key filter types, .../filter/type
+const (
+	filterLC6  = 0 // low cut, 6 dB per octave
+	filterLC12 = 1
+	filterHC6  = 2 // high cut
+	filterHC12 = 3
+	filterBP1  = 4 // band pass, 1 octave wide
+	filterBP2  = 5
+	filterBP3  = 6
+	filterBP5  = 7
+	filterBP10 = 8
+)
+
+// keyFilter filters the side chain which triggers a gate or compressor
+type keyFilter struct {
+	on         bool
+	filterType int
+	freq       float64 // Hz
+}
+
+type gate struct {
+	on        bool
+	mode      int     // e.g. gateGate
+	threshold float64 // dB
+	rangeDB   float64 // dB of attenuation when closed
+	attack    float64 // ms
+	hold      float64 // ms
+	release   float64 // ms
+	keySource int     // 0 for the channel itself
+	filter    keyFilter
+}
+
+type dynamics struct {
+	on        bool
+	mode      int     // dynComp or dynExp
+	threshold float64 // dB
+	ratio     float64 // one of dynRatios
+	knee      float64
+	gain      float64 // make up gain, dB
+	mix       float64 // percent of compressed signal
+	attack    float64 // ms
+	hold      float64 // ms
+	release   float64 // ms
+	keySource int     // 0 for the channel itself
+	filter    keyFilter
+}
+
+func getGatePath(ch int) string {
+	// Only the 32 channels have a gate, e.g. /ch/01/gate
+	if ch < 0 || ch > 31 {
+		return ""
+	}
+	return getChannelIDPath(ch) + "/gate"
+}
+
+func getDynPath(ch int) string {
+	// e.g. /ch/01/dyn
+	if ch < 0 || ch > 31 {
+		return ""
+	}
+	return getChannelIDPath(ch) + "/dyn"
+}
+
+func nearestRatio(ratio float64) int {
+	// Returns the index of the ratio in dynRatios closest to ratio
+	nearest := 0
+	for i, r := range dynRatios {
+		if math.Abs(r-ratio) < math.Abs(dynRatios[nearest]-ratio) {
+			nearest = i
+		}
+	}
+	return nearest
+}
+
+func (m *mixer) getGate(channelID int) (g gate, err error) {
+	path := getGatePath(channelID)
+	if path == "" {
+		return g, fmt.Errorf("channelID %d has no gate", channelID)
+	}
+	r := paramReader{m: m, path: path}
+	g.on = r.getInt("/on") != 0
+	g.mode = int(r.getInt("/mode"))
+	g.threshold = r.getScaled("/thr", gateThresholdScale)
+	g.rangeDB = r.getScaled("/range", gateRangeScale)
+	g.attack = r.getScaled("/attack", attackScale)
+	g.hold = r.getScaled("/hold", holdScale)
+	g.release = r.getScaled("/release", releaseScale)
+	g.keySource = int(r.getInt("/keysrc"))
+	g.filter = r.getFilter()
+	return g, r.err
+}
+
+func (m *mixer) setGate(channelID int, g gate) error {
+	// Sets every value of the gate, each rounded to the console's nearest step
+	path := getGatePath(channelID)
+	if path == "" {
+		return fmt.Errorf("channelID %d has no gate", channelID)
+	}
+	if g.mode < gateExp2 || g.mode > gateDuck {
+		return fmt.Errorf("no gate mode %d", g.mode)
+	}
+	for _, err := range []error{
+		gateThresholdScale.check("gate threshold", g.threshold),
+		gateRangeScale.check("gate range", g.rangeDB),
+		attackScale.check("gate attack", g.attack),
+		holdScale.check("gate hold", g.hold),
+		releaseScale.check("gate release", g.release),
+		checkKey(g.keySource, g.filter),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	w := paramWriter{m: m, channelID: channelID, pathOf: getGatePath}
+	w.setInt("/on", boolToInt(g.on))
+	w.setInt("/mode", int32(g.mode))
+	w.setFloat("/thr", gateThresholdScale.toFloat(g.threshold))
+	w.setFloat("/range", gateRangeScale.toFloat(g.rangeDB))
+	w.setFloat("/attack", attackScale.toFloat(g.attack))
+	w.setFloat("/hold", holdScale.toFloat(g.hold))
+	w.setFloat("/release", releaseScale.toFloat(g.release))
+	w.setInt("/keysrc", int32(g.keySource))
+	w.setFilter(g.filter)
+	return w.err
+}
+
+func (m *mixer) getDynamics(channelID int) (d dynamics, err error) {
+	path := getDynPath(channelID)
+	if path == "" {
+		return d, fmt.Errorf("channelID %d has no dynamics", channelID)
+	}
+	r := paramReader{m: m, path: path}
+	d.on = r.getInt("/on") != 0
+	d.mode = int(r.getInt("/mode"))
+	d.threshold = r.getScaled("/thr", dynThresholdScale)
+	if i := int(r.getInt("/ratio")); i >= 0 && i < len(dynRatios) {
+		d.ratio = dynRatios[i]
+	}
+	d.knee = r.getScaled("/knee", dynKneeScale)
+	d.gain = r.getScaled("/mgain", dynGainScale)
+	d.mix = r.getScaled("/mix", dynMixScale)
+	d.attack = r.getScaled("/attack", attackScale)
+	d.hold = r.getScaled("/hold", holdScale)
+	d.release = r.getScaled("/release", releaseScale)
+	d.keySource = int(r.getInt("/keysrc"))
+	d.filter = r.getFilter()
+	return d, r.err
+}
+
+func (m *mixer) setDynamics(channelID int, d dynamics) error {
+	// Sets every value of the dynamics, each rounded to the console's nearest step
+	//     The ratio is the nearest the console offers, see dynRatios
+	path := getDynPath(channelID)
+	if path == "" {
+		return fmt.Errorf("channelID %d has no dynamics", channelID)
+	}
+	if d.mode != dynComp && d.mode != dynExp {
+		return fmt.Errorf("no dynamics mode %d", d.mode)
+	}
+	for _, err := range []error{
+		dynThresholdScale.check("dynamics threshold", d.threshold),
+		dynKneeScale.check("dynamics knee", d.knee),
+		dynGainScale.check("dynamics gain", d.gain),
+		dynMixScale.check("dynamics mix", d.mix),
+		attackScale.check("dynamics attack", d.attack),
+		holdScale.check("dynamics hold", d.hold),
+		releaseScale.check("dynamics release", d.release),
+		checkKey(d.keySource, d.filter),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	w := paramWriter{m: m, channelID: channelID, pathOf: getDynPath}
+	w.setInt("/on", boolToInt(d.on))
+	w.setInt("/mode", int32(d.mode))
+	w.setFloat("/thr", dynThresholdScale.toFloat(d.threshold))
+	w.setInt("/ratio", int32(nearestRatio(d.ratio)))
+	w.setFloat("/knee", dynKneeScale.toFloat(d.knee))
+	w.setFloat("/mgain", dynGainScale.toFloat(d.gain))
+	w.setFloat("/mix", dynMixScale.toFloat(d.mix))
+	w.setFloat("/attack", attackScale.toFloat(d.attack))
+	w.setFloat("/hold", holdScale.toFloat(d.hold))
+	w.setFloat("/release", releaseScale.toFloat(d.release))
+	w.setInt("/keysrc", int32(d.keySource))
+	w.setFilter(d.filter)
+	return w.err
+}
+
+func checkKey(keySource int, filter keyFilter) error {
+	// Key sources are 0 for self, then the console's 64 inputs and buses
+	if keySource < 0 || keySource > 64 {
+		return fmt.Errorf("no key source %d", keySource)
+	}
+	if filter.filterType < filterLC6 || filter.filterType > filterBP10 {
+		return fmt.Errorf("no key filter type %d", filter.filterType)
+	}
+	if filter.freq < minEQFreq || filter.freq > maxEQFreq {
+		return fmt.Errorf("key filter frequency %.0f Hz outside %.0f Hz to %.0f Hz", filter.freq, minEQFreq, maxEQFreq)
+	}
+	return nil
+}
+
+// paramReader reads the values of a block such as /ch/01/gate,
+// keeping the first error so each value need not be checked
+type paramReader struct {
+	m    *mixer
+	path string
+	err  error
+}
+
+func (r *paramReader) getInt(name string) int32 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := r.m.queryInt(r.path + name)
+	r.err = err
+	return v
+}
+
+func (r *paramReader) getScaled(name string, s paramScale) float64 {
+	if r.err != nil {
+		return 0
+	}
+	f, err := r.m.queryFloat(r.path + name)
+	r.err = err
+	return s.fromFloat(f)
+}
+
+func (r *paramReader) getFilter() (f keyFilter) {
+	// The key filter shares the frequency scale of the EQ
+	f.on = r.getInt("/filter/on") != 0
+	f.filterType = int(r.getInt("/filter/type"))
+	if r.err != nil {
+		return f
+	}
+	v, err := r.m.queryFloat(r.path + "/filter/f")
+	r.err = err
+	f.freq = floatToFreq(v)
+	return f
+}
+
+// paramWriter writes the values of a block on a channel and its linked partner,
+// stopping at the first error
+type paramWriter struct {
+	m         *mixer
+	channelID int
+	pathOf    func(ch int) string
+	err       error
+}
+
+func (w *paramWriter) write(name string, addValue func(msg *osc.Message)) {
+	if w.err != nil {
+		return
+	}
+	w.err = w.m.sendLinked(w.channelID, func(id int) string {
+		return w.pathOf(id) + name
+	}, addValue)
+}
+
+func (w *paramWriter) setInt(name string, v int32) {
+	w.write(name, func(msg *osc.Message) { msg.AddInt(v) })
+}
+
+func (w *paramWriter) setFloat(name string, v float32) {
+	w.write(name, func(msg *osc.Message) { msg.AddFloat(v) })
+}
+
+func (w *paramWriter) setFilter(f keyFilter) {
+	w.setInt("/filter/on", boolToInt(f.on))
+	w.setInt("/filter/type", int32(f.filterType))
+	w.setFloat("/filter/f", freqToFloat(f.freq))
+}
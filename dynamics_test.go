@@ -0,0 +1,133 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParamScale(t *testing.T) {
+	for _, tc := range []struct {
+		s paramScale
+		v float64
+		f float32
+	}{
+		{gateThresholdScale, -80, 0},
+		{gateThresholdScale, -40, 0.5},
+		{gateThresholdScale, -40.2, 0.5}, // rounded to a 0.5 dB step
+		{dynThresholdScale, -30, 0.5},
+		{attackScale, 60, 0.5},
+		{holdScale, 2000, 1},
+		{releaseScale, math.Sqrt(5 * 4000), 0.5},
+	} {
+		if got := tc.s.toFloat(tc.v); got != tc.f {
+			t.Errorf("%+v.toFloat(%v) = %v, want %v", tc.s, tc.v, got, tc.f)
+		}
+	}
+	// Every step of the console converts back to itself
+	for _, s := range []paramScale{gateThresholdScale, gateRangeScale, dynThresholdScale,
+		dynKneeScale, dynGainScale, dynMixScale, attackScale, holdScale, releaseScale} {
+		for i := 0; i <= s.steps; i++ {
+			f := float32(i) / float32(s.steps)
+			if got := s.toFloat(s.fromFloat(f)); got != f {
+				t.Fatalf("%+v: step %v round trips to %v", s, f, got)
+			}
+		}
+	}
+	if got := gateThresholdScale.fromFloat(gateThresholdScale.toFloat(-37.5)); got != -37.5 {
+		t.Errorf("gate threshold -37.5 dB round trips to %v", got)
+	}
+	if got := dynRatios[nearestRatio(3.5)]; got != 3 && got != 4 {
+		t.Errorf("nearest ratio to 3.5 = %v", got)
+	}
+}
+
+func TestGate(t *testing.T) {
+	m, console := newTestMixer(t)
+	want := gate{
+		on:        true,
+		mode:      gateGate,
+		threshold: -40,
+		rangeDB:   30,
+		attack:    5,
+		hold:      20,
+		release:   200,
+		keySource: 2,
+		filter:    keyFilter{on: true, filterType: filterBP2, freq: 1000},
+	}
+	if err := m.setGate(0, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.getGate(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Logarithmic values come back at the console's nearest step
+	if got.on != want.on || got.mode != want.mode || got.threshold != want.threshold ||
+		got.rangeDB != want.rangeDB || got.attack != want.attack || got.keySource != want.keySource ||
+		!near(got.hold, want.hold, 0.1) || !near(got.release, want.release, 0.05) ||
+		got.filter.on != want.filter.on || got.filter.filterType != want.filter.filterType ||
+		!near(got.filter.freq, want.filter.freq, 0.02) {
+		t.Errorf("gate = %+v, want %+v", got, want)
+	}
+	eventually(t, "gate to reach the console", func() bool {
+		return consoleFloat(t, console, "/ch/01/gate/thr") == 0.5
+	})
+
+	bad := want
+	bad.threshold = -90
+	if err := m.setGate(0, bad); err == nil {
+		t.Error("set a gate threshold below -80 dB")
+	}
+	if _, err := m.getGate(32); err == nil {
+		t.Error("an aux in has a gate")
+	}
+}
+
+func TestDynamics(t *testing.T) {
+	m, _ := newTestMixer(t)
+	want := dynamics{
+		on:        true,
+		mode:      dynComp,
+		threshold: -20,
+		ratio:     4,
+		knee:      2,
+		gain:      6,
+		mix:       50,
+		attack:    10,
+		hold:      1,
+		release:   100,
+		filter:    keyFilter{filterType: filterLC12, freq: 100},
+	}
+	if err := m.setDynamics(31, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.getDynamics(31)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.on != want.on || got.mode != want.mode || got.threshold != want.threshold ||
+		got.ratio != want.ratio || got.knee != want.knee || got.gain != want.gain ||
+		got.mix != want.mix || got.attack != want.attack ||
+		!near(got.hold, want.hold, 0.1) || !near(got.release, want.release, 0.05) ||
+		got.filter.filterType != want.filter.filterType || !near(got.filter.freq, want.filter.freq, 0.02) {
+		t.Errorf("dynamics = %+v, want %+v", got, want)
+	}
+
+	// The ratio is the nearest the console offers
+	want.ratio = 3.8
+	if err := m.setDynamics(31, want); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := m.getDynamics(31); err != nil || got.ratio != 4 {
+		t.Errorf("ratio = %v, %v; want 4", got.ratio, err)
+	}
+	want.mode = 2
+	if err := m.setDynamics(31, want); err == nil {
+		t.Error("set an unknown dynamics mode")
+	}
+}
+
+func near(got, want, tolerance float64) bool {
+	// Reports whether got is within a fraction tolerance of want
+	return math.Abs(got-want) <= math.Abs(want)*tolerance
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// Channels, aux ins and fx returns have a 4 band EQ, e.g. /ch/01/eq/2/f,
+// and buses, matrices and mains have a 6 band EQ.
+// The console sends each value as a float from 0 to 1, in steps:
+//
+//	f  frequency  20 Hz to 20 kHz, logarithmic, 201 steps
+//	g  gain       -15 dB to +15 dB, linear, 121 steps of 0.25 dB
+//	q  Q          10 to 0.3, logarithmic, 72 steps
+const (
+	minEQFreq  = 20.0
+	maxEQFreq  = 20000.0
+	eqFreqStep = 200
+	maxEQGain  = 15.0
+	eqGainStep = 120
+	maxEQQ     = 10.0
+	minEQQ     = 0.3
+	eqQStep    = 71
+)
+
+// The type of an EQ band, /.../eq/N/type
+const (
+	eqLowCut    = 0
+	eqLowShelf  = 1
+	eqPEQ       = 2 // parametric
+	eqVEQ       = 3 // vintage parametric
+	eqHighShelf = 4
+	eqHighCut   = 5
+	eqTypeCount = 6
+)
+
+var eqTypeNames = [eqTypeCount]string{"LCut", "LShv", "PEQ", "VEQ", "HShv", "HCut"}
+
+// eqBand holds one band of an EQ in engineering units
+type eqBand struct {
+	bandType int
+	freq     float64 // Hz
+	gain     float64 // dB
+	q        float64
+}
+
+func (b eqBand) String() string {
+	// e.g. "PEQ 250 Hz -3.00 dB Q 2.0"
+	name := "?"
+	if b.bandType >= 0 && b.bandType < eqTypeCount {
+		name = eqTypeNames[b.bandType]
+	}
+	return fmt.Sprintf("%s %.0f Hz %+.2f dB Q %.1f", name, b.freq, b.gain, b.q)
+}
+
+func quantize(f float64, steps int) float32 {
+	// Rounds f, from 0 to 1, to the nearest of steps equal steps
+	f = min(max(f, 0), 1)
+	return float32(math.Round(f*float64(steps)) / float64(steps))
+}
+
+func freqToFloat(hz float64) float32 {
+	return quantize(math.Log(hz/minEQFreq)/math.Log(maxEQFreq/minEQFreq), eqFreqStep)
+}
+
+func floatToFreq(f float32) float64 {
+	return minEQFreq * math.Pow(maxEQFreq/minEQFreq, float64(f))
+}
+
+func gainToFloat(db float64) float32 {
+	return quantize((db+maxEQGain)/(2*maxEQGain), eqGainStep)
+}
+
+func floatToGain(f float32) float64 {
+	// Counted in whole steps so that e.g. -3 dB comes back exactly
+	step := math.Round(float64(f) * eqGainStep)
+	return step*(2*maxEQGain/eqGainStep) - maxEQGain
+}
+
+func qToFloat(q float64) float32 {
+	// A higher Q is a narrower band, and a lower float
+	return quantize(math.Log(q/maxEQQ)/math.Log(minEQQ/maxEQQ), eqQStep)
+}
+
+func floatToQ(f float32) float64 {
+	return maxEQQ * math.Pow(minEQQ/maxEQQ, float64(f))
+}
+
+func eqBandCount(ch int) int {
+	switch {
+	case ch < 0:
+		return 0
+	case ch < 48: // channels, aux ins and fx returns
+		return 4
+	case ch < 72: // buses, matrices and mains
+		return 6
+	default: // dcas
+		return 0
+	}
+}
+
+func getEQPath(ch int) string {
+	// e.g. channel 1: /ch/01/eq
+	if eqBandCount(ch) == 0 {
+		return ""
+	}
+	return getChannelIDPath(ch) + "/eq"
+}
+
+func getEQBandPath(ch, band int) string {
+	// Bands are numbered from 1 as on the console, e.g. /ch/01/eq/2
+	if band < 1 || band > eqBandCount(ch) {
+		return ""
+	}
+	return fmt.Sprintf("%s/eq/%d", getChannelIDPath(ch), band)
+}
+
+func (m *mixer) isEQOn(channelID int) (bool, error) {
+	path := getEQPath(channelID)
+	if path == "" {
+		return false, fmt.Errorf("channelID %d has no eq", channelID)
+	}
+	on, err := m.queryInt(path + "/on")
+	return on != 0, err
+}
+
+func (m *mixer) setEQOn(channelID int, on bool) error {
+	if getEQPath(channelID) == "" {
+		return fmt.Errorf("channelID %d has no eq", channelID)
+	}
+	return m.sendLinked(channelID, func(id int) string {
+		return getEQPath(id) + "/on"
+	}, func(msg *osc.Message) {
+		msg.AddInt(boolToInt(on))
+	})
+}
+
+func (m *mixer) getEQBand(channelID, band int) (b eqBand, err error) {
+	path := getEQBandPath(channelID, band)
+	if path == "" {
+		return b, fmt.Errorf("channelID %d has no eq band %d", channelID, band)
+	}
+	bandType, err := m.queryInt(path + "/type")
+	if err != nil {
+		return b, err
+	}
+	f, err := m.queryFloat(path + "/f")
+	if err != nil {
+		return b, err
+	}
+	g, err := m.queryFloat(path + "/g")
+	if err != nil {
+		return b, err
+	}
+	q, err := m.queryFloat(path + "/q")
+	if err != nil {
+		return b, err
+	}
+	return eqBand{
+		bandType: int(bandType),
+		freq:     floatToFreq(f),
+		gain:     floatToGain(g),
+		q:        floatToQ(q),
+	}, nil
+}
+
+func (m *mixer) setEQBand(channelID, band int, b eqBand) error {
+	// Sets every value of the band, each rounded to the console's nearest step
+	//     e.g. eqBand{eqPEQ, 250, -3, 2} for 250 Hz, -3 dB, Q 2
+	if getEQBandPath(channelID, band) == "" {
+		return fmt.Errorf("channelID %d has no eq band %d", channelID, band)
+	}
+	if b.bandType < 0 || b.bandType >= eqTypeCount {
+		return fmt.Errorf("no eq type %d", b.bandType)
+	}
+	if b.freq < minEQFreq || b.freq > maxEQFreq {
+		return fmt.Errorf("eq frequency %.0f Hz outside %.0f Hz to %.0f Hz", b.freq, minEQFreq, maxEQFreq)
+	}
+	if math.Abs(b.gain) > maxEQGain {
+		return fmt.Errorf("eq gain %+.2f dB outside ±%.0f dB", b.gain, maxEQGain)
+	}
+	if b.q < minEQQ || b.q > maxEQQ {
+		return fmt.Errorf("eq Q %.2f outside %.1f to %.0f", b.q, minEQQ, maxEQQ)
+	}
+	params := []struct {
+		name     string
+		addValue func(msg *osc.Message)
+	}{
+		{"/type", func(msg *osc.Message) { msg.AddInt(int32(b.bandType)) }},
+		{"/f", func(msg *osc.Message) { msg.AddFloat(freqToFloat(b.freq)) }},
+		{"/g", func(msg *osc.Message) { msg.AddFloat(gainToFloat(b.gain)) }},
+		{"/q", func(msg *osc.Message) { msg.AddFloat(qToFloat(b.q)) }},
+	}
+	for _, p := range params {
+		err := m.sendLinked(channelID, func(id int) string {
+			return getEQBandPath(id, band) + p.name
+		}, p.addValue)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
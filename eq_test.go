@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEQConversions(t *testing.T) {
+	for _, tc := range []struct {
+		f    float32
+		freq float64
+	}{
+		{0, 20},
+		{0.5, 632.46},
+		{1, 20000},
+	} {
+		if got := floatToFreq(tc.f); math.Abs(got-tc.freq) > 0.01 {
+			t.Errorf("floatToFreq(%v) = %v, want %v", tc.f, got, tc.freq)
+		}
+		if got := freqToFloat(tc.freq); got != tc.f {
+			t.Errorf("freqToFloat(%v) = %v, want %v", tc.freq, got, tc.f)
+		}
+	}
+	if got := floatToGain(gainToFloat(-3)); got != -3 {
+		t.Errorf("gain -3 dB round trips to %v", got)
+	}
+	if got := floatToGain(gainToFloat(-3.1)); got != -3 {
+		t.Errorf("gain -3.1 dB is %v, want the 0.25 dB step -3", got)
+	}
+	if q := floatToQ(0); q != 10 {
+		t.Errorf("floatToQ(0) = %v, want 10", q)
+	}
+	if q := floatToQ(1); math.Abs(q-0.3) > 1e-9 {
+		t.Errorf("floatToQ(1) = %v, want 0.3", q)
+	}
+	// Every step of the console converts back to itself
+	for i := 0; i <= eqFreqStep; i++ {
+		f := float32(i) / eqFreqStep
+		if got := freqToFloat(floatToFreq(f)); got != f {
+			t.Fatalf("frequency step %v round trips to %v", f, got)
+		}
+	}
+	for i := 0; i <= eqQStep; i++ {
+		f := float32(i) / eqQStep
+		if got := qToFloat(floatToQ(f)); got != f {
+			t.Fatalf("Q step %v round trips to %v", f, got)
+		}
+	}
+}
+
+func TestEQ(t *testing.T) {
+	m, console := newTestMixer(t)
+	want := eqBand{bandType: eqPEQ, freq: 250, gain: -3, q: 2}
+	if err := m.setEQBand(0, 2, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.getEQBand(0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Values are rounded to the console's steps
+	if got.bandType != want.bandType || got.gain != want.gain ||
+		math.Abs(got.freq-want.freq)/want.freq > 0.02 ||
+		math.Abs(got.q-want.q)/want.q > 0.03 {
+		t.Errorf("band 2 = %v, want %v", got, want)
+	}
+	eventually(t, "band to reach the console", func() bool {
+		return consoleFloat(t, console, "/ch/01/eq/2/g") == 0.4
+	})
+
+	if err := m.setEQOn(48, true); err != nil {
+		t.Fatal(err)
+	}
+	if on, err := m.isEQOn(48); err != nil || !on {
+		t.Errorf("bus eq on = %v, %v; want on", on, err)
+	}
+	// Buses have 6 bands, channels 4
+	if _, err := m.getEQBand(48, 6); err != nil {
+		t.Errorf("bus band 6: %v", err)
+	}
+	if _, err := m.getEQBand(0, 5); err == nil {
+		t.Error("channel has a fifth band")
+	}
+	for _, bad := range []eqBand{
+		{bandType: 6, freq: 1000, q: 1},
+		{bandType: eqPEQ, freq: 10, q: 1},
+		{bandType: eqPEQ, freq: 1000, gain: 16, q: 1},
+		{bandType: eqPEQ, freq: 1000, q: 20},
+	} {
+		if err := m.setEQBand(0, 1, bad); err == nil {
+			t.Errorf("set band %v", bad)
+		}
+	}
+}
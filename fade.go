@@ -2,17 +2,34 @@ package main
 
 import (
 	"fmt"
-	"net"
 	"time"
 
 	"github.com/grogersstephen/x32app/osc"
 )
 
 func (m *mixer) makeFade(channelID int, start, stop float32, fadeDuration time.Duration) error {
-	// Send a series of osc messages to the mixer.conn
+	// Send a series of osc messages to the mixer.client
 	//     which cause the fader of the given channelID to fade from
 	//     the value indicated by start to the value indicated by stop
 	//     over the duration of fadeDuration
+	//   A linked pair fades together
+	f := m.faders[channelID]
+	if !f.claim() {
+		return fmt.Errorf("fader currently in motion")
+	}
+	defer f.deactivate()
+	var paths []string
+	for _, id := range m.withPartner(channelID) {
+		paths = append(paths, getFaderPath(id))
+	}
+	return m.fadePaths(f, paths, start, stop, fadeDuration)
+}
+
+func (m *mixer) fadePaths(f *fader, paths []string, start, stop float32, fadeDuration time.Duration) error {
+	// Fades every path, each a fader law level such as a fader or a send,
+	//     from start to stop over fadeDuration
+	//   The fade runs while f is active, and is stopped by f.deactivate
+	//     The caller claims f first and releases it when this returns
 
 	// Get start and stop in terms of faderResolution
 	startI, err := unitToFaderValue(start, m.faderResolution)
@@ -24,38 +41,32 @@ func (m *mixer) makeFade(channelID int, start, stop float32, fadeDuration time.D
 		return fmt.Errorf("invalid stop value")
 	}
 
-	// Get the messages
-	messages, err := m.getFadeMessages(channelID, startI, stopI, fadeDuration)
-	if err != nil {
-		return err
+	// Get the packets, one for each path in turn at every step
+	steps := make([][][]byte, len(paths))
+	for i, path := range paths {
+		steps[i] = m.getFadePackets(path, startI, stopI)
 	}
+	packets := interleave(steps...)
 
 	// Find the desired interval delay between each message
-	interval := getInterval(getDist(startI, stopI), fadeDuration)
-
-	// Set fader active flag
-	m.faders[channelID].activate()
+	interval := getInterval(getDist(startI, stopI)*len(paths), fadeDuration)
 
 	// Trigger the messages
-	err = m.faders[channelID].triggerFade(m.conn, messages, interval)
-	if err != nil {
-		return err
-	}
-
-	// Set fader inactive
-	m.faders[channelID].deactivate()
-	return nil
+	return f.triggerFade(m.client, packets, interval)
 }
 
-func (f *fader) triggerFade(conn net.Conn, messages []osc.Message, interval time.Duration) error {
+func (f *fader) triggerFade(client *osc.Client, packets [][]byte, interval time.Duration) error {
+	if client == nil {
+		return fmt.Errorf("no connection made")
+	}
 	var failureCount int // keep count of how many attempts fail to send
-	for i := range messages {
+	for i := range packets {
 		// Check active status
-		if !f.active {
+		if !f.active.Load() {
 			return fmt.Errorf("fade interrupted")
 		}
-		// Send message
-		err := osc.Send(conn, messages[i])
+		// Send packet
+		_, err := client.Write(packets[i])
 		// Count failures
 		switch err {
 		case nil:
@@ -71,7 +82,7 @@ func (f *fader) triggerFade(conn net.Conn, messages []osc.Message, interval time
 	}
 	return nil
 }
-func (m *mixer) getFadeMessages(channelID int, startI, stopI int, fadeDuration time.Duration) (messages []osc.Message, err error) {
+func (m *mixer) getFadePackets(path string, startI, stopI int) (packets [][]byte) {
 
 	// define the step value
 	step := 1
@@ -79,25 +90,44 @@ func (m *mixer) getFadeMessages(channelID int, startI, stopI int, fadeDuration t
 		step = -1
 	}
 
-	// Write a list of osc messages
+	// Every packet is the same size, so encode them all into one buffer
+	steps := getDist(startI, stopI)
+	size := len(osc.AppendString(nil, path)) + 8 // type tags and a float32
+	buf := make([]byte, 0, steps*size)
+	packets = make([][]byte, 0, steps)
+
+	// Write a list of osc packets
 	// Start at startI and inc/dec until stopI
 	for i := startI; i != stopI; i += step {
-		// Create a new osc.Message with the address of the channelID
-		msg := osc.NewMessage(getFaderPath(channelID))
+		start := len(buf)
+		// Encode the address
+		buf = osc.AppendString(buf, path)
+		buf = osc.AppendTypeTags(buf, "f")
 		// divide i by mixer.faderResolution to get a value on scale 0 - 1
 		v := float32(i) / m.faderResolution
-		// append the value to the osc.Message
-		msg.AddFloat(v)
-		err = msg.MakePacket()
-		if err != nil {
-			return messages, err
-		}
+		// append the value to the packet
+		buf = osc.AppendFloat32(buf, v)
 
-		// Append the OSC Message to the list
-		messages = append(messages, msg)
+		// Append the packet to the list
+		packets = append(packets, buf[start:len(buf):len(buf)])
 	}
 
-	return messages, err
+	return packets
+}
+
+func interleave(lists ...[][]byte) [][]byte {
+	// Returns a[0], b[0], a[1], b[1]... for lists a, b...
+	//     Every list must be the same length
+	if len(lists) == 1 {
+		return lists[0]
+	}
+	packets := make([][]byte, 0, len(lists)*len(lists[0]))
+	for i := range lists[0] {
+		for _, list := range lists {
+			packets = append(packets, list[i])
+		}
+	}
+	return packets
 }
 
 func getInterval(dist int, d time.Duration) time.Duration {
@@ -107,18 +137,24 @@ func getInterval(dist int, d time.Duration) time.Duration {
 	return interval
 }
 
+func (m *mixer) fadeToDB(channelID int, db float64, fadeDuration time.Duration) error {
+	// Fade given channel to the level db, e.g. -10 for -10 dB
+	return m.fadeTo(channelID, dbToFader(db), fadeDuration)
+}
+
 func (m *mixer) fadeTo(channelID int, target float32, fadeDuration time.Duration) error {
 	// Fade given channel
 	//     from its current level to the given target level
 	//     over the duration define by fadeDuration
 	//   The target should be a value between 0 and 1
+	//     see dbToFader and parseLevel for targets in dB
 
 	if m.isInMotion(channelID) {
 		return fmt.Errorf("fader currently in motion")
 	}
 
 	// Get current level of the fader
-	currentLevel, err := m.faders[channelID].getLevel(m.conn)
+	currentLevel, err := m.getLevel(channelID)
 	if err != nil {
 		return err
 	}
@@ -131,7 +167,8 @@ func (m *mixer) fadeTo(channelID int, target float32, fadeDuration time.Duration
 
 func unitToFaderValue(u float32, faderResolution float32) (int, error) {
 	// Converts a 'unit interval' (values in set [0,1]) float32 to an int in terms of faderResolution
-	if u < 0 || u > 1 {
+	//   NaN fails every comparison, so the range is checked as !(0 <= u <= 1)
+	if !(u >= 0 && u <= 1) {
 		return -1, fmt.Errorf("invalid unit interval value")
 	}
 	return int(u * faderResolution), nil
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The X32 fader is a float from 0 to 1 which maps to dB in four linear segments,
+// following the unofficial x32 osc protocol:
+//
+//	0.5    - 1      -10 dB to +10 dB
+//	0.25   - 0.5    -30 dB to -10 dB
+//	0.0625 - 0.25   -60 dB to -30 dB
+//	0      - 0.0625 -90 dB to -60 dB, where 0 is -inf
+const (
+	maxFaderDB = 10
+	minFaderDB = -90
+)
+
+func faderToDB(f float32) float64 {
+	// Returns the level in dB of the fader value f
+	//     0 and below is -inf
+	v := float64(f)
+	switch {
+	case v <= 0:
+		return math.Inf(-1)
+	case v >= 1:
+		return maxFaderDB
+	case v >= 0.5:
+		return v*40 - 30
+	case v >= 0.25:
+		return v*80 - 50
+	case v >= 0.0625:
+		return v*160 - 70
+	default:
+		return v*480 - 90
+	}
+}
+
+func dbToFader(db float64) float32 {
+	// Returns the fader value of the level db
+	//     Levels above +10 dB are clamped, levels at or below -90 dB are 0
+	switch {
+	case math.IsNaN(db), db <= minFaderDB:
+		return 0
+	case db >= maxFaderDB:
+		return 1
+	case db >= -10:
+		return float32((db + 30) / 40)
+	case db >= -30:
+		return float32((db + 50) / 80)
+	case db >= -60:
+		return float32((db + 70) / 160)
+	default:
+		return float32((db + 90) / 480)
+	}
+}
+
+func formatDB(db float64) string {
+	// e.g. "-10.0 dB", "+2.5 dB" or "-inf dB"
+	if math.IsInf(db, -1) {
+		return "-inf dB"
+	}
+	return fmt.Sprintf("%+.1f dB", db)
+}
+
+func parseLevel(s string) (float32, error) {
+	// Returns the fader value of a level given either in dB,
+	//     e.g. "-10dB", "0 dB", "+5db" or "-inf",
+	//     or as a raw fader value from 0.00 to 1.00
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "-inf", "-oo", "-inf db", "-infdb":
+		return 0, nil
+	}
+	if number, ok := strings.CutSuffix(s, "db"); ok {
+		db, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse level %q", s)
+		}
+		// NaN fails every comparison, so would slip past the range check
+		//     -inf dB is silence, but +inf dB is no level at all
+		if math.IsNaN(db) || math.IsInf(db, 1) {
+			return 0, fmt.Errorf("cannot parse level %q", s)
+		}
+		if db > maxFaderDB {
+			return 0, fmt.Errorf("level %s above %+d dB", formatDB(db), maxFaderDB)
+		}
+		return dbToFader(db), nil
+	}
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse level %q: give dB, e.g. -10dB, or 0.00 to 1.00", s)
+	}
+	if math.IsNaN(f) || f < 0 || f > 1 {
+		return 0, fmt.Errorf("level %v outside 0.00 to 1.00", f)
+	}
+	return float32(f), nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFaderLaw(t *testing.T) {
+	for _, tc := range []struct {
+		fader float32
+		db    float64
+	}{
+		{1, 10},
+		{0.75, 0},
+		{0.5, -10},
+		{0.375, -20},
+		{0.25, -30},
+		{0.0625, -60},
+		{0.03125, -75},
+	} {
+		if got := faderToDB(tc.fader); math.Abs(got-tc.db) > 1e-4 {
+			t.Errorf("faderToDB(%v) = %v, want %v", tc.fader, got, tc.db)
+		}
+		if got := dbToFader(tc.db); math.Abs(float64(got-tc.fader)) > 1e-6 {
+			t.Errorf("dbToFader(%v) = %v, want %v", tc.db, got, tc.fader)
+		}
+	}
+	if db := faderToDB(0); !math.IsInf(db, -1) {
+		t.Errorf("faderToDB(0) = %v, want -inf", db)
+	}
+	if f := dbToFader(math.Inf(-1)); f != 0 {
+		t.Errorf("dbToFader(-inf) = %v, want 0", f)
+	}
+	// Every step of the fader converts back to itself
+	for i := 0; i <= 1024; i++ {
+		f := float32(i) / 1024
+		if got := dbToFader(faderToDB(f)); math.Abs(float64(got-f)) > 1e-6 {
+			t.Fatalf("round trip of %v gives %v", f, got)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want float32
+	}{
+		{"0dB", 0.75},
+		{"-10dB", 0.5},
+		{" -30 db ", 0.25},
+		{"+10dB", 1},
+		{"-inf", 0},
+		{"-inf dB", 0},
+		{"-infinity dB", 0},
+		{"-100dB", 0},
+		{"0.5", 0.5},
+		{"1", 1},
+	} {
+		got, err := parseLevel(tc.in)
+		if err != nil || math.Abs(float64(got-tc.want)) > 1e-6 {
+			t.Errorf("parseLevel(%q) = %v, %v; want %v", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"", "loud", "11dB", "1.5", "-0.1", "dB", "nan", "NaN dB", "inf", "+inf", "inf dB", "infinity"} {
+		if _, err := parseLevel(in); err == nil {
+			t.Errorf("parseLevel(%q) did not fail", in)
+		}
+	}
+}
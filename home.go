@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"image/color"
+	"os"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -23,6 +24,8 @@ type homeScreen struct {
 	fadeOutB     *widget.Button
 	killCurrentB *widget.Button
 	killAllB     *widget.Button
+	muteCheck    *widget.Check   // mute of the selected channel
+	muteGroups   []*widget.Check // the six mute groups
 	renameChB    *widget.Button
 	closeB       *widget.Button
 	status       *widget.Label
@@ -61,6 +64,20 @@ func (h *homeScreen) setupAUXBank() {
 	}
 }
 
+func (h *homeScreen) setupMutes() {
+	h.muteCheck = widget.NewCheck("Mute", h.mutePress)
+	h.muteGroups = make([]*widget.Check, muteGroupCount)
+	for i := range h.muteGroups {
+		group := i + 1
+		h.muteGroups[i] = widget.NewCheck(
+			fmt.Sprintf("MG%d", group),
+			func(on bool) {
+				h.muteGroupPress(group, on)
+			},
+		)
+	}
+}
+
 func (h *homeScreen) setupChannelBank() {
 	h.channelBank = make([]*widget.Button, 32)
 	for i := 0; i < 32; i++ {
@@ -92,13 +109,15 @@ func (h *homeScreen) setup() {
 	// Set up the levelLabel which will show the fader level of the selected channel
 	h.levelLabel = widget.NewLabel("")
 	// Set up Fade To button
-	h.fadeTo = setupButtonLine("\nFade To(0.00 to 1.00): \n", h.fadeToPress, "1", "")
+	h.fadeTo = setupButtonLine("\nFade To(dB, or 0.00 to 1.00): \n", h.fadeToPress, "0dB", "")
 	// Set up Fade Out button
 	h.fadeOutB = widget.NewButton("\nFade Out\n", h.fadeOutPress)
 	// Set up Kill current button
 	h.killCurrentB = widget.NewButton("\nSTOPP\n", h.killCurrent)
 	// Set up Kill all button
 	h.killAllB = widget.NewButton("\nSTOP ALL\n", h.killAll)
+	// Set up the mute toggles, which show the console's state once connected
+	h.setupMutes()
 	// Set up Rename Ch Button
 	h.renameChB = widget.NewButton("\nRename\n", h.renameChPress)
 	// Set up close button
@@ -109,6 +128,13 @@ func (h *homeScreen) setup() {
 	h.console = newConsole("")
 	// Set up the mixer with channel, dca, and bus send counts
 	h.mixer = newX32()
+	// Record the session with the console when X32APP_RECORD names a file
+	if path := os.Getenv("X32APP_RECORD"); path != "" {
+		err := h.mixer.startRecording(path)
+		if err != nil {
+			h.console.log(err.Error())
+		}
+	}
 	// Set up the fader select button banks
 	h.setupChannelBank()
 	h.setupDCABank()
@@ -154,6 +180,11 @@ func (h *homeScreen) getContent() *fyne.Container {
 				h.killCurrentB,
 			),
 			h.killAllB,
+			container.NewGridWithColumns(7,
+				h.muteCheck,
+				h.muteGroups[0], h.muteGroups[1], h.muteGroups[2],
+				h.muteGroups[3], h.muteGroups[4], h.muteGroups[5],
+			),
 			//h.renameChB,
 			h.console.scroller,
 			container.NewGridWithColumns(2,
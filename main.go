@@ -14,7 +14,6 @@ func main() {
 
 	// Set the default ports
 	// TODO: save default ports elsewhere to reference if these variables are not set
-	App.Preferences().SetInt("LevelMonitorPort", 10024)
 	App.Preferences().SetInt("LPort", 10023)
 	App.Preferences().SetInt("RPort", 10023)
 
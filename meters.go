@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// Following the unofficial x32 osc protocol, the console streams meters
+// for 10 seconds after a /meters request. Each reply carries one blob:
+// an int32 count followed by that many float32 values, all little endian.
+// A value of 1.0 is 0 dBFS
+const (
+	// 32 channels, 8 aux, 8 fx returns, 16 buses, 6 matrices, post fader
+	//     Value i is the meter of channelID i
+	metersOverview = 0
+	// 32 channels pre fader, then 32 gate and 32 dynamics gain reductions
+	metersInputs = 1
+	// 16 buses, 6 matrices, main L, R and mono, then their gain reductions
+	metersBuses = 2
+)
+
+// Number of values in each meter bank's reply, zero where it varies
+var meterBankSizes = [16]int{70, 96, 49, 22, 82, 27, 4, 16, 6, 32, 32, 5, 4, 48, 0, 0}
+
+type channelMeter struct {
+	pre       float32 // level after trim, before the fader
+	post      float32 // level after the fader
+	gate      float32 // gate gain reduction
+	dyn       float32 // dynamics gain reduction
+	updatedAt time.Time
+}
+
+type meterState struct {
+	mu       sync.Mutex
+	channels []channelMeter // indexed by channelID
+}
+
+func decodeMeterBlob(blob []byte) ([]float32, error) {
+	// Returns the float32 values of a /meters reply blob
+	words, err := decodeBlobWords(blob)
+	if err != nil {
+		return nil, fmt.Errorf("meter %w", err)
+	}
+	values := make([]float32, len(words))
+	for i, w := range words {
+		values[i] = math.Float32frombits(w)
+	}
+	return values, nil
+}
+
+func meterToDB(v float32) float64 {
+	// Converts a linear meter value to dBFS
+	if v <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(float64(v))
+}
+
+func subscribeMeters(client *osc.Client, bank int, args []int32, timeFactor int32, onValues func(values []float32)) (*subscription, error) {
+	// Asks the console to stream the meter bank, renewing until cancelled
+	//     args are the bank's own arguments, e.g. the channel for /meters/6
+	//     timeFactor slows the stream to one reply every 50ms * timeFactor
+	if bank < 0 || bank >= len(meterBankSizes) {
+		return nil, fmt.Errorf("no meter bank %d", bank)
+	}
+	address := fmt.Sprintf("/meters/%d", bank)
+	request := osc.NewMessage("/meters")
+	request.AddString(address)
+	for _, arg := range args {
+		request.AddInt(arg)
+	}
+	if timeFactor > 0 {
+		request.AddInt(timeFactor)
+	}
+
+	return startSubscription(client, address, request, func(reply osc.Message) {
+		blob, err := reply.ArgBlob(0)
+		if err != nil {
+			return
+		}
+		values, err := decodeMeterBlob(blob)
+		if err != nil {
+			return
+		}
+		if size := meterBankSizes[bank]; size > 0 && len(values) != size {
+			return
+		}
+		onValues(values)
+	})
+}
+
+func (m *mixer) startMeters(client *osc.Client) (stop func(), err error) {
+	// Subscribes to the meter banks which cover every channelID with a meter
+	//     DCAs have no meters
+	//   Call stop to end the subscriptions
+	banks := []struct {
+		bank   int
+		update func(values []float32)
+	}{
+		{metersOverview, m.updateOverviewMeters},
+		{metersInputs, m.updateInputMeters},
+		{metersBuses, m.updateBusMeters},
+	}
+	var subs []*subscription
+	stop = func() {
+		for _, s := range subs {
+			s.cancel()
+		}
+	}
+	for _, b := range banks {
+		s, err := subscribeMeters(client, b.bank, nil, 0, b.update)
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return stop, nil
+}
+
+func (m *mixer) updateOverviewMeters(values []float32) {
+	// Post fader levels of channelIDs 0 - 69
+	m.meters.mu.Lock()
+	defer m.meters.mu.Unlock()
+	now := time.Now()
+	for id, v := range values {
+		m.meters.channels[id].post = v
+		m.meters.channels[id].updatedAt = now
+	}
+}
+
+func (m *mixer) updateInputMeters(values []float32) {
+	// Pre fader levels and gain reductions of the 32 channels
+	m.meters.mu.Lock()
+	defer m.meters.mu.Unlock()
+	now := time.Now()
+	for ch := 0; ch < 32; ch++ {
+		m.meters.channels[ch].pre = values[ch]
+		m.meters.channels[ch].gate = values[32+ch]
+		m.meters.channels[ch].dyn = values[64+ch]
+		m.meters.channels[ch].updatedAt = now
+	}
+}
+
+func (m *mixer) updateBusMeters(values []float32) {
+	// Buses, matrices and mains, with their dynamics gain reductions
+	//     values: 16 buses, 6 matrices, main L, main R, mono,
+	//     then a gain reduction for the buses, matrices, main LR and mono
+	m.meters.mu.Lock()
+	defer m.meters.mu.Unlock()
+	now := time.Now()
+	for i := 0; i < 22; i++ {
+		id := 48 + i
+		m.meters.channels[id].post = values[i]
+		m.meters.channels[id].dyn = values[25+i]
+		m.meters.channels[id].updatedAt = now
+	}
+	// The stereo main shows the louder side
+	m.meters.channels[70].post = max(values[22], values[23])
+	m.meters.channels[70].dyn = values[47]
+	m.meters.channels[70].updatedAt = now
+	m.meters.channels[71].post = values[24]
+	m.meters.channels[71].dyn = values[48]
+	m.meters.channels[71].updatedAt = now
+}
+
+func (m *mixer) getMeter(channelID int) (channelMeter, bool) {
+	// Returns the latest meters of the channel
+	//     and whether they arrived within the last second
+	m.meters.mu.Lock()
+	defer m.meters.mu.Unlock()
+	if channelID < 0 || channelID >= len(m.meters.channels) {
+		return channelMeter{}, false
+	}
+	meter := m.meters.channels[channelID]
+	return meter, time.Since(meter.updatedAt) < time.Second
+}
+
+func (cm channelMeter) String() string {
+	// Shows the post fader level, as the console's own meters do
+	return fmt.Sprintf("%.1f dB", meterToDB(cm.post))
+}
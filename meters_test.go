@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+func meterBlob(count int, values []float32) []byte {
+	// An int32 count then the values, all little endian
+	//     The count need not match the values, to build malformed blobs
+	blob := binary.LittleEndian.AppendUint32(nil, uint32(count))
+	for _, v := range values {
+		blob = binary.LittleEndian.AppendUint32(blob, math.Float32bits(v))
+	}
+	return blob
+}
+
+func rampValues(n int) []float32 {
+	// Distinct values, so a value read from the wrong index shows
+	values := make([]float32, n)
+	for i := range values {
+		values[i] = float32(i+1) / 128
+	}
+	return values
+}
+
+func TestDecodeMeterBlob(t *testing.T) {
+	inputs := rampValues(meterBankSizes[metersInputs])
+	tests := []struct {
+		name    string
+		blob    []byte
+		want    []float32
+		wantErr bool
+	}{
+		{"inputs bank", meterBlob(len(inputs), inputs), inputs, false},
+		{"empty", meterBlob(0, nil), []float32{}, false},
+		{"no count", []byte{1, 0}, nil, true},
+		{"fewer values than counted", meterBlob(96, inputs[:95]), nil, true},
+		{"partial value", append(meterBlob(1, nil), 0, 0), nil, true},
+		{"negative count", meterBlob(-1, inputs), nil, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decodeMeterBlob(tc.blob)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("decoded %d values, want an error", len(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("decoded %d values, want %d", len(got), len(tc.want))
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("value %d = %v, want %v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMeterBanks(t *testing.T) {
+	// Each value of each bank lands on the right channelID
+	m := newX32()
+	overview := rampValues(meterBankSizes[metersOverview])
+	inputs := rampValues(meterBankSizes[metersInputs])
+	buses := rampValues(meterBankSizes[metersBuses])
+	m.updateOverviewMeters(overview)
+	m.updateInputMeters(inputs)
+	m.updateBusMeters(buses)
+
+	tests := []struct {
+		channelID int
+		want      channelMeter
+	}{
+		{0, channelMeter{post: overview[0], pre: inputs[0], gate: inputs[32], dyn: inputs[64]}},
+		{31, channelMeter{post: overview[31], pre: inputs[31], gate: inputs[63], dyn: inputs[95]}},
+		{32, channelMeter{post: overview[32]}},              // aux in 1
+		{47, channelMeter{post: overview[47]}},              // fx return 8
+		{48, channelMeter{post: buses[0], dyn: buses[25]}},  // bus 1
+		{63, channelMeter{post: buses[15], dyn: buses[40]}}, // bus 16
+		{64, channelMeter{post: buses[16], dyn: buses[41]}}, // matrix 1
+		{69, channelMeter{post: buses[21], dyn: buses[46]}}, // matrix 6
+		{70, channelMeter{post: buses[23], dyn: buses[47]}}, // main, the louder of L and R
+		{71, channelMeter{post: buses[24], dyn: buses[48]}}, // mono
+	}
+	for _, tc := range tests {
+		got, fresh := m.getMeter(tc.channelID)
+		if !fresh {
+			t.Errorf("channelID %d: meter not updated", tc.channelID)
+		}
+		got.updatedAt = time.Time{}
+		if got != tc.want {
+			t.Errorf("channelID %d: meter %+v, want %+v", tc.channelID, got, tc.want)
+		}
+	}
+	if _, fresh := m.getMeter(72); fresh {
+		t.Error("DCA 1 has a meter")
+	}
+}
+
+func TestSubscribeMetersDropsMalformed(t *testing.T) {
+	// A console on loopback which answers the /meters request by hand
+	console, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer console.Close()
+	conn, err := net.Dial("udp", console.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := osc.NewClient(conn)
+	defer client.Close()
+
+	received := make(chan []float32, 4)
+	s, err := subscribeMeters(client, metersInputs, nil, 0, func(values []float32) {
+		received <- values
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.cancel()
+
+	buf := make([]byte, osc.MaxPacketSize)
+	console.SetReadDeadline(time.Now().Add(time.Second))
+	_, from, err := console.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := func(blob []byte) {
+		msg := osc.NewMessage("/meters/1")
+		msg.AddBlob(blob)
+		packet, err := msg.AppendPacket(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := console.WriteTo(packet, from); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inputs := rampValues(meterBankSizes[metersInputs])
+	reply(meterBlob(10, inputs[:10])) // well formed, but not the bank's size
+	reply(meterBlob(96, inputs[:50])) // counts more values than it holds
+	reply([]byte{0xff})               // too short for a count
+	reply(meterBlob(len(inputs), inputs))
+	select {
+	case values := <-received:
+		if len(values) != len(inputs) || values[95] != inputs[95] {
+			t.Errorf("received %d values, want the %d sent", len(values), len(inputs))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no meters received")
+	}
+	select {
+	case values := <-received:
+		t.Errorf("received a second reply of %d values, want malformed ones dropped", len(values))
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// The X32 has six mute groups.
+// Each is switched by /config/mute/N, and a channel's membership
+// is a bitmask at .../grp/mute where bit 0 is group 1
+const muteGroupCount = 6
+
+func (m *mixer) isMuted(channelID int) (bool, error) {
+	// The console stores whether a channel is on, which is not muted
+	path := getOnPath(channelID)
+	if path == "" {
+		return false, fmt.Errorf("channelID %d has no mute", channelID)
+	}
+	reply, err := m.query(path)
+	if err != nil {
+		return false, err
+	}
+	on, err := reply.ArgInt(0)
+	if err != nil {
+		return false, fmt.Errorf("could not get mute of channelID %d: %w", channelID, err)
+	}
+	return on == 0, nil
+}
+
+func (m *mixer) setMute(channelID int, muted bool) error {
+	// Mutes, or unmutes, the channel and its linked partner
+	if getOnPath(channelID) == "" {
+		return fmt.Errorf("channelID %d has no mute", channelID)
+	}
+	return m.sendLinked(channelID, getOnPath, func(msg *osc.Message) {
+		msg.AddInt(boolToInt(!muted))
+	})
+}
+
+func (m *mixer) isMuteGroupOn(group int) (bool, error) {
+	// Groups are numbered 1 - 6 as on the console
+	if group < 1 || group > muteGroupCount {
+		return false, fmt.Errorf("no mute group %d", group)
+	}
+	reply, err := m.query(fmt.Sprintf("/config/mute/%d", group))
+	if err != nil {
+		return false, err
+	}
+	on, err := reply.ArgInt(0)
+	if err != nil {
+		return false, fmt.Errorf("could not get mute group %d: %w", group, err)
+	}
+	return on != 0, nil
+}
+
+func (m *mixer) setMuteGroup(group int, on bool) error {
+	// Mutes, or unmutes, every member of the group
+	if group < 1 || group > muteGroupCount {
+		return fmt.Errorf("no mute group %d", group)
+	}
+	msg := osc.NewMessage(fmt.Sprintf("/config/mute/%d", group))
+	msg.AddInt(boolToInt(on))
+	return m.send(msg)
+}
+
+func (m *mixer) getMuteGroups(channelID int) (groups []int, err error) {
+	// Returns the mute groups the channel belongs to, e.g. [1 4]
+	mask, err := m.getMuteGroupMask(channelID)
+	if err != nil {
+		return nil, err
+	}
+	for group := 1; group <= muteGroupCount; group++ {
+		if mask&(1<<(group-1)) != 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+func (m *mixer) setMuteGroupMember(channelID, group int, member bool) error {
+	// Adds the channel to, or removes it from, the mute group
+	//     leaving its other groups as they are
+	if group < 1 || group > muteGroupCount {
+		return fmt.Errorf("no mute group %d", group)
+	}
+	mask, err := m.getMuteGroupMask(channelID)
+	if err != nil {
+		return err
+	}
+	bit := int32(1) << (group - 1)
+	if member {
+		mask |= bit
+	} else {
+		mask &^= bit
+	}
+	msg := osc.NewMessage(getMuteGroupPath(channelID))
+	msg.AddInt(mask)
+	return m.send(msg)
+}
+
+func (m *mixer) getMuteGroupMask(channelID int) (int32, error) {
+	path := getMuteGroupPath(channelID)
+	if path == "" {
+		return 0, fmt.Errorf("channelID %d cannot join mute groups", channelID)
+	}
+	reply, err := m.query(path)
+	if err != nil {
+		return 0, err
+	}
+	mask, err := reply.ArgInt(0)
+	if err != nil {
+		return 0, fmt.Errorf("could not get mute groups of channelID %d: %w", channelID, err)
+	}
+	return mask, nil
+}
+
+func boolToInt(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
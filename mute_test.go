@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+func TestMute(t *testing.T) {
+	m, console := newTestMixer(t)
+	for _, id := range []int{0, 40, 70, 79} {
+		if muted, err := m.isMuted(id); err != nil || muted {
+			t.Fatalf("channelID %d muted = %v, %v; want unmuted", id, muted, err)
+		}
+		if err := m.setMute(id, true); err != nil {
+			t.Fatal(err)
+		}
+		path := getOnPath(id)
+		eventually(t, path, func() bool {
+			msg, _ := console.Get(path)
+			on, err := msg.ArgInt(0)
+			return err == nil && on == 0
+		})
+		if muted, err := m.isMuted(id); err != nil || !muted {
+			t.Errorf("channelID %d muted = %v, %v; want muted", id, muted, err)
+		}
+	}
+
+	// An unmute on the console surface is seen
+	msg := osc.NewMessage("/dca/8/on")
+	msg.AddInt(1)
+	console.Set(msg)
+	eventually(t, "unmute", func() bool {
+		muted, err := m.isMuted(79)
+		return err == nil && !muted
+	})
+}
+
+func TestMuteGroups(t *testing.T) {
+	m, console := newTestMixer(t)
+	if err := m.setMuteGroupMember(4, 2, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.setMuteGroupMember(4, 5, true); err != nil {
+		t.Fatal(err)
+	}
+	groups, err := m.getMuteGroups(4)
+	if err != nil || !reflect.DeepEqual(groups, []int{2, 5}) {
+		t.Errorf("groups = %v, %v; want [2 5]", groups, err)
+	}
+	if err := m.setMuteGroupMember(4, 2, false); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "membership to reach the console", func() bool {
+		msg, _ := console.Get("/ch/05/grp/mute")
+		mask, err := msg.ArgInt(0)
+		return err == nil && mask == 1<<4
+	})
+
+	if err := m.setMuteGroup(3, true); err != nil {
+		t.Fatal(err)
+	}
+	if on, err := m.isMuteGroupOn(3); err != nil || !on {
+		t.Errorf("group 3 on = %v, %v; want on", on, err)
+	}
+
+	if _, err := m.getMuteGroups(72); err == nil {
+		t.Error("a dca joined a mute group")
+	}
+	if err := m.setMuteGroup(7, true); err == nil {
+		t.Error("set a seventh mute group")
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// A consoleNode is one line of the X32 node text protocol.
+// The console answers /node with the values of every parameter in a block,
+// in console order, e.g. /ch/01/config "Kick" 1 RD 1,
+// and accepts the same line sent to "/" as a write of the whole block.
+// A line may stop short, leaving the values after it as they are.
+// Values are shown in the console's display units, e.g. -12.5 for a fader
+// in dB or %000101 for a bitmask, not as OSC arguments
+type consoleNode struct {
+	path   string
+	values []string
+}
+
+// Node requests are pipelined and all replies share the /node address,
+// so only one batch of requests may be in flight at a time
+var nodeMu sync.Mutex
+
+// The console replies to /node at "node", without the leading slash.
+// "/node" is handled too, as other OSC servers for the X32 use it
+var nodeReplyAddresses = []string{"node", "/node"}
+
+func parseNode(line string) (n consoleNode, err error) {
+	// Splits a node line into its path and values
+	//     Quoted values may contain spaces, and are returned unquoted
+	line = strings.TrimRight(line, "\n\x00")
+	fields, err := splitNode(line)
+	if err != nil {
+		return n, err
+	}
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return n, fmt.Errorf("node has no path: %q", line)
+	}
+	n.path = fields[0]
+	n.values = fields[1:]
+	return n, nil
+}
+
+func splitNode(line string) (fields []string, err error) {
+	// Values are separated by spaces
+	//     A quoted part may hold spaces and Go escapes, e.g. "Lead \"Vox\""
+	var field strings.Builder
+	inField := false
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case '"':
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, fmt.Errorf("unterminated quote in node: %q", line)
+			}
+			s, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("bad quoted value in node: %q", line)
+			}
+			field.WriteString(s)
+			inField = true
+			i = end + 1
+		case ' ':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+			i++
+		default:
+			field.WriteByte(line[i])
+			inField = true
+			i++
+		}
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+func (n consoleNode) String() string {
+	// Formats the node as the console expects to receive it
+	//     Values which are empty, contain spaces,
+	//     or need escaping are quoted
+	var sb strings.Builder
+	sb.WriteString(n.path)
+	for _, v := range n.values {
+		sb.WriteByte(' ')
+		quoted := strconv.Quote(v)
+		if v == "" || strings.Contains(v, " ") || quoted[1:len(quoted)-1] != v {
+			sb.WriteString(quoted)
+			continue
+		}
+		sb.WriteString(v)
+	}
+	return sb.String()
+}
+
+func (n consoleNode) value(i int) (string, error) {
+	if i < 0 || i >= len(n.values) {
+		return "", fmt.Errorf("node %s has no value %d", n.path, i)
+	}
+	return n.values[i], nil
+}
+
+func (m *mixer) getNodes(paths ...string) (map[string]consoleNode, error) {
+	// Requests every node at once and collects the replies as they arrive
+	//     Requests still unanswered after the inquiry timeout are sent again
+	//   Paths are given with a leading slash, e.g. "/ch/01/config"
+	client := m.client
+	if client == nil {
+		return nil, fmt.Errorf("no connection made")
+	}
+	nodeMu.Lock()
+	defer nodeMu.Unlock()
+
+	var mu sync.Mutex
+	nodes := make(map[string]consoleNode, len(paths))
+	arrived := make(chan struct{}, 1)
+	handle := func(reply osc.Message) {
+		text, err := reply.ArgString(0)
+		if err != nil {
+			return
+		}
+		n, err := parseNode(text)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		nodes[n.path] = n
+		mu.Unlock()
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+	}
+	for _, address := range nodeReplyAddresses {
+		remove := client.HandleFunc(address, handle)
+		defer remove()
+	}
+
+	missing := func() (left []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, path := range paths {
+			if _, ok := nodes[path]; !ok {
+				left = append(left, path)
+			}
+		}
+		return left
+	}
+
+	for try := 0; try <= inquireOptions.Retries; try++ {
+		for _, path := range missing() {
+			msg := osc.NewMessage("/node")
+			msg.AddString(strings.TrimPrefix(path, "/"))
+			if err := client.Send(msg); err != nil {
+				return nil, err
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), inquireOptions.Timeout)
+		for len(missing()) > 0 && ctx.Err() == nil {
+			select {
+			case <-arrived:
+			case <-ctx.Done():
+			case <-client.Done():
+				cancel()
+				return nil, osc.ErrClientClosed
+			}
+		}
+		cancel()
+		if len(missing()) == 0 {
+			break
+		}
+	}
+
+	if left := missing(); len(left) > 0 {
+		return nodes, fmt.Errorf("no reply for %d of %d nodes: %w", len(left), len(paths), osc.ErrNoReply)
+	}
+	return nodes, nil
+}
+
+func (m *mixer) setNode(n consoleNode) error {
+	// Writes every value of the node in one message
+	msg := osc.NewMessage("/")
+	msg.AddString(n.String())
+	return m.send(msg)
+}
+
+func getConfigNodePath(ch int) string {
+	path := getChannelIDPath(ch)
+	if path == "" {
+		return path
+	}
+	return path + "/config"
+}
+
+func (m *mixer) getNames(channelIDs ...int) (names map[int]string, err error) {
+	// Reads the name of every given channel from its config node
+	//     The name is the first value of the node on every kind of channel
+	//   Names which did arrive are returned along with any error
+	paths := make([]string, 0, len(channelIDs))
+	for _, ch := range channelIDs {
+		paths = append(paths, getConfigNodePath(ch))
+	}
+	nodes, err := m.getNodes(paths...)
+	names = make(map[int]string, len(nodes))
+	for _, ch := range channelIDs {
+		n, ok := nodes[getConfigNodePath(ch)]
+		if !ok {
+			continue
+		}
+		name, nameErr := n.value(0)
+		if nameErr != nil {
+			continue
+		}
+		names[ch] = name
+	}
+	return names, err
+}
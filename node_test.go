@@ -0,0 +1,157 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+func TestParseNode(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    consoleNode
+		wantErr bool
+	}{
+		{
+			line: "/ch/01/config \"Kick\" 1 RD 1\n",
+			want: consoleNode{"/ch/01/config", []string{"Kick", "1", "RD", "1"}},
+		},
+		{
+			line: `/ch/02/config "Lead Vox" 1 YE 2`,
+			want: consoleNode{"/ch/02/config", []string{"Lead Vox", "1", "YE", "2"}},
+		},
+		{
+			line: `/ch/03/config "" 1 OFF 3`,
+			want: consoleNode{"/ch/03/config", []string{"", "1", "OFF", "3"}},
+		},
+		{
+			line: `/ch/04/config "Say \"Hi\"" 1 BL 4`,
+			want: consoleNode{"/ch/04/config", []string{`Say "Hi"`, "1", "BL", "4"}},
+		},
+		{
+			line: `/ch/05/config "Back\\slash" 1 BL 5`,
+			want: consoleNode{"/ch/05/config", []string{`Back\slash`, "1", "BL", "5"}},
+		},
+		{
+			// Bitmasks and levels in display units
+			line: "/ch/01/grp %000101 %00000000",
+			want: consoleNode{"/ch/01/grp", []string{"%000101", "%00000000"}},
+		},
+		{
+			line: "/ch/01/mix ON  -12.5 ON +0 OFF -oo\x00",
+			want: consoleNode{"/ch/01/mix", []string{"ON", "-12.5", "ON", "+0", "OFF", "-oo"}},
+		},
+		{
+			line: "/main/st/config",
+			want: consoleNode{"/main/st/config", []string{}},
+		},
+		{line: "", wantErr: true},
+		{line: "ch/01/config Kick", wantErr: true},
+		{line: `"/ch/01/config" Kick`, want: consoleNode{"/ch/01/config", []string{"Kick"}}},
+		{line: `/ch/01/config "Kick 1 RD 1`, wantErr: true},
+		{line: `/ch/01/config "Kick\" 1 RD 1`, wantErr: true},
+		{line: `/ch/01/config "K\qck" 1 RD 1`, wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := parseNode(tc.line)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseNode(%q) = %v, want an error", tc.line, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseNode(%q): %v", tc.line, err)
+			continue
+		}
+		if got.path != tc.want.path || !reflect.DeepEqual(got.values, tc.want.values) {
+			t.Errorf("parseNode(%q) = %q %q, want %q %q", tc.line, got.path, got.values, tc.want.path, tc.want.values)
+		}
+	}
+}
+
+func TestNodeString(t *testing.T) {
+	tests := []struct {
+		node consoleNode
+		want string
+	}{
+		{consoleNode{"/ch/01/config", []string{"Kick", "1", "RD", "1"}}, "/ch/01/config Kick 1 RD 1"},
+		{consoleNode{"/ch/02/config", []string{"Lead Vox", "1", "YE", "2"}}, `/ch/02/config "Lead Vox" 1 YE 2`},
+		{consoleNode{"/ch/03/config", []string{"", "1", "OFF", "3"}}, `/ch/03/config "" 1 OFF 3`},
+		{consoleNode{"/ch/04/config", []string{`Say "Hi"`}}, `/ch/04/config "Say \"Hi\""`},
+		{consoleNode{"/ch/05/config", []string{`Back\slash`}}, `/ch/05/config "Back\\slash"`},
+		{consoleNode{"/ch/01/grp", []string{"%000101", "%00000000"}}, "/ch/01/grp %000101 %00000000"},
+	}
+	for _, tc := range tests {
+		got := tc.node.String()
+		if got != tc.want {
+			t.Errorf("String() = %s, want %s", got, tc.want)
+		}
+		// Formatting then parsing gives back the node
+		parsed, err := parseNode(got)
+		if err != nil || parsed.path != tc.node.path || !reflect.DeepEqual(parsed.values, tc.node.values) {
+			t.Errorf("parseNode(%s) = %q %q, %v; want %q %q", got, parsed.path, parsed.values, err, tc.node.path, tc.node.values)
+		}
+	}
+}
+
+func TestMirrorNode(t *testing.T) {
+	cm := newConsoleMirror()
+	cm.setLive(true)
+	cm.apply(faderValue(0.5))
+	color := osc.NewMessage("/ch/01/config/color")
+	color.AddInt(1)
+	cm.apply(color)
+
+	// A node write replaces the block
+	write := osc.NewMessage("/")
+	write.AddString(consoleNode{"/ch/01/config", []string{"Kick", "1", "RD", "1"}}.String())
+	packet, err := write.AppendPacket(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm.applyPacket(packet)
+
+	msg, ok := cm.get("/ch/01/config/name")
+	if name, err := msg.ArgString(0); !ok || err != nil || name != "Kick" {
+		t.Errorf("mirrored name = %q, %v, %v; want Kick", name, ok, err)
+	}
+	if _, ok := cm.get("/ch/01/config/color"); ok {
+		t.Error("mirror kept a value from before the node write")
+	}
+	if _, ok := cm.get("/ch/01/mix/fader"); !ok {
+		t.Error("mirror lost a value outside the node")
+	}
+
+	// So does a reply to /node, which the console sends at "node"
+	reply := osc.NewMessage("node")
+	reply.AddString("/ch/01/config \"Snare\" 1 YE 2\n")
+	cm.apply(reply)
+	msg, ok = cm.get("/ch/01/config/name")
+	if name, err := msg.ArgString(0); !ok || err != nil || name != "Snare" {
+		t.Errorf("mirrored name = %q, %v, %v; want Snare", name, ok, err)
+	}
+}
+
+func TestSetNode(t *testing.T) {
+	m, console := newTestMixer(t)
+	n := consoleNode{"/ch/02/config", []string{"Lead Vox", "1", "YE", "2"}}
+	if err := m.setNode(n); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the node write to reach the console", func() bool {
+		msg, _ := console.Get("/ch/02/config/name")
+		name, _ := msg.ArgString(0)
+		return name == "Lead Vox"
+	})
+
+	// And it reads back through /node
+	nodes, err := m.getNodes("/ch/02/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, err := nodes["/ch/02/config"].value(0); err != nil || name != "Lead Vox" {
+		t.Errorf("node name = %q, %v; want Lead Vox", name, err)
+	}
+}
@@ -0,0 +1,50 @@
+package osc
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Zero bytes used to pad each part of a packet to a multiple of 4 bytes
+var zeroPad [4]byte
+
+// The Append functions encode a single part of an OSC packet onto dst.
+// They let a caller build packets in a reused buffer without allocating,
+// e.g. a fader move:
+//
+//	buf = AppendString(buf[:0], "/ch/01/mix/fader")
+//	buf = AppendTypeTags(buf, "f")
+//	buf = AppendFloat32(buf, 0.75)
+
+func AppendString(dst []byte, s string) []byte {
+	// Appends s with one to four zero bytes
+	dst = append(dst, s...)
+	return append(dst, zeroPad[:zeroBytesToAdd(len(s))]...)
+}
+
+func AppendTypeTags(dst []byte, typeTags string) []byte {
+	// Appends the type tag string, adding the leading comma
+	dst = append(dst, ',')
+	dst = append(dst, typeTags...)
+	return append(dst, zeroPad[:zeroBytesToAdd(len(typeTags)+1)]...)
+}
+
+func AppendInt32(dst []byte, x int32) []byte {
+	return binary.BigEndian.AppendUint32(dst, uint32(x))
+}
+
+func AppendFloat32(dst []byte, x float32) []byte {
+	return binary.BigEndian.AppendUint32(dst, math.Float32bits(x))
+}
+
+func AppendBlob(dst []byte, b []byte) []byte {
+	// Appends the size of b, b itself, and any padding
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(b)))
+	dst = append(dst, b...)
+	return append(dst, zeroPad[:blobPadding(len(b))]...)
+}
+
+func appendPadded(dst []byte, b []byte) []byte {
+	dst = append(dst, b...)
+	return append(dst, zeroPad[:zeroBytesToAdd(len(b))]...)
+}
@@ -0,0 +1,134 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// A Packet is either a Message or a Bundle
+type Packet interface {
+	MakePacket() error
+	AppendPacket(dst []byte) ([]byte, error)
+	Bytes() []byte
+}
+
+// Every bundle begins with the OSC-string "#bundle"
+var bundleTag = []byte("#bundle\x00")
+
+type Bundle struct {
+	Packet   bytes.Buffer
+	Timetag  Timetag
+	Elements []Packet // each element is a *Message or a *Bundle
+}
+
+func NewBundle(tt Timetag) Bundle {
+	bundle := Bundle{
+		Timetag: tt,
+	}
+	return bundle
+}
+
+func (b *Bundle) AddMessage(msg Message) {
+	b.Elements = append(b.Elements, &msg)
+}
+
+func (b *Bundle) AddBundle(bundle Bundle) {
+	b.Elements = append(b.Elements, &bundle)
+}
+
+func (b *Bundle) Bytes() []byte {
+	return b.Packet.Bytes()
+}
+
+func (msg *Message) Bytes() []byte {
+	return msg.Packet.Bytes()
+}
+
+func (b *Bundle) Messages() (messages []Message) {
+	// Returns every message within the bundle in order,
+	//     including those held in nested bundles
+	for _, elem := range b.Elements {
+		switch e := elem.(type) {
+		case *Message:
+			messages = append(messages, *e)
+		case *Bundle:
+			messages = append(messages, e.Messages()...)
+		}
+	}
+	return messages
+}
+
+func (b *Bundle) MakePacket() error {
+	// Ensure b.Packet is empty
+	b.Packet.Reset()
+
+	// Encode straight into the space the buffer already has
+	byt, err := b.AppendPacket(b.Packet.AvailableBuffer())
+	if err != nil {
+		return err
+	}
+	b.Packet.Write(byt)
+	return nil
+}
+
+func (b *Bundle) AppendPacket(dst []byte) ([]byte, error) {
+	// Appends the encoded bundle to dst and returns the extended slice
+
+	// A bundle is the "#bundle" string followed by an 8 byte time tag
+	dst = append(dst, bundleTag...)
+	dst = binary.BigEndian.AppendUint64(dst, uint64(b.Timetag))
+
+	// Each element is written as an int32 size count followed by its contents
+	//     Elements may themselves be bundles
+	for _, elem := range b.Elements {
+		sizeAt := len(dst)
+		dst = append(dst, zeroPad[:]...)
+		var err error
+		dst, err = elem.AppendPacket(dst)
+		if err != nil {
+			return dst, err
+		}
+		// Fill in the size now that the element is written
+		binary.BigEndian.PutUint32(dst[sizeAt:], uint32(len(dst)-sizeAt-4))
+	}
+
+	return dst, nil
+}
+
+func (b *Bundle) ParseBundle() error {
+	// Parses an OSC bundle in the form of []byte into a Bundle type as defined above
+	//     Nested messages and bundles are parsed as with ParseMessage
+	d := decoder{data: b.Packet.Bytes()}
+	return d.decodeBundle(b)
+}
+
+func isBundle(byt []byte) bool {
+	return bytes.HasPrefix(byt, bundleTag)
+}
+
+func ParsePacket(byt []byte) (Packet, error) {
+	// Parses a []byte into either a *Message or a *Bundle
+	//     depending on whether it begins with "#bundle"
+	return parsePacket(byt, false)
+}
+
+func ParsePacketStrict(byt []byte) (Packet, error) {
+	// Parses like ParsePacket, but as ParseMessageStrict does
+	return parsePacket(byt, true)
+}
+
+func parsePacket(byt []byte, strict bool) (Packet, error) {
+	d := decoder{data: byt, strict: strict}
+	if isBundle(byt) {
+		var b Bundle
+		b.Packet.Write(byt)
+		d.data = b.Packet.Bytes()
+		err := d.decodeBundle(&b)
+		return &b, err
+	}
+	var msg Message
+	msg.Packet.Write(byt)
+	d.data = msg.Packet.Bytes()
+	err := d.decodeMessage(&msg)
+	return &msg, err
+}
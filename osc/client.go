@@ -0,0 +1,266 @@
+package osc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Client shares one connection between many goroutines.
+// A single read loop receives every packet, passes each reply to the
+// goroutines inquiring at its address, and forwards unsolicited
+// messages to the handlers registered with Handle
+type Client struct {
+	conn net.Conn
+	mux  *ServeMux
+
+	mu      sync.Mutex
+	waiters map[string][]chan Message
+
+	done chan struct{}
+	err  error
+}
+
+// ErrClientClosed is returned by Client methods once the client is closed
+var ErrClientClosed = errors.New("client closed")
+
+func NewClient(conn net.Conn) *Client {
+	// Takes ownership of conn and starts reading from it
+	c := &Client{
+		conn:    conn,
+		mux:     NewServeMux(),
+		waiters: make(map[string][]chan Message),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+	return c
+}
+
+func (c *Client) Conn() net.Conn {
+	return c.conn
+}
+
+func (c *Client) Send(msg Message) error {
+	return c.SendPacket(&msg)
+}
+
+func (c *Client) SendPacket(p Packet) error {
+	select {
+	case <-c.done:
+		return ErrClientClosed
+	default:
+	}
+	return SendPacket(c.conn, p)
+}
+
+func (c *Client) Write(packet []byte) (int, error) {
+	// Sends an already encoded packet, such as one built with AppendPacket
+	select {
+	case <-c.done:
+		return 0, ErrClientClosed
+	default:
+	}
+	return c.conn.Write(packet)
+}
+
+func (c *Client) Handle(pattern string, handler Handler) (remove func()) {
+	// Registers a handler for unsolicited messages matching the pattern
+	//     Call remove to unregister this handler alone
+	return c.mux.Handle(pattern, handler)
+}
+
+func (c *Client) HandleFunc(pattern string, handler func(msg Message)) (remove func()) {
+	return c.mux.HandleFunc(pattern, handler)
+}
+
+func (c *Client) Remove(pattern string) {
+	c.mux.Remove(pattern)
+}
+
+func (c *Client) Inquire(ctx context.Context, msg Message, opts InquireOptions) (reply Message, err error) {
+	// Sends the message and waits for a reply with a matching address
+	//     Behaves like InquireContext but is safe to call from many goroutines
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultInquireTimeout
+	}
+	want := opts.ReplyAddress
+	if want == "" {
+		want = string(trimZeroBytesRight(msg.Address))
+	}
+
+	// Register before sending so a fast reply cannot be missed
+	ch := c.wait(want)
+	defer c.unwait(want, ch)
+
+	for attempt := 0; attempt <= opts.Retries; attempt++ {
+		err = c.Send(msg)
+		if err != nil {
+			return reply, err
+		}
+		timer := time.NewTimer(timeout)
+		select {
+		case reply = <-ch:
+			timer.Stop()
+			return reply, nil
+		case <-ctx.Done():
+			timer.Stop()
+			return reply, ctx.Err()
+		case <-c.done:
+			timer.Stop()
+			return reply, ErrClientClosed
+		case <-timer.C:
+		}
+	}
+
+	return reply, ErrNoReply
+}
+
+func (c *Client) wait(address string) chan Message {
+	ch := make(chan Message, 1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waiters[address] = append(c.waiters[address], ch)
+	return ch
+}
+
+func (c *Client) unwait(address string, ch chan Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	waiting := c.waiters[address]
+	for i := range waiting {
+		if waiting[i] == ch {
+			waiting = append(waiting[:i], waiting[i+1:]...)
+			break
+		}
+	}
+	if len(waiting) == 0 {
+		delete(c.waiters, address)
+		return
+	}
+	c.waiters[address] = waiting
+}
+
+func (c *Client) deliver(msg Message) {
+	// Passes a reply to every goroutine waiting on its address
+	//     Messages nobody is waiting for go to the registered handlers
+	address := string(trimZeroBytesRight(msg.Address))
+	c.mu.Lock()
+	waiting := c.waiters[address]
+	delivered := false
+	for _, ch := range waiting {
+		select {
+		case ch <- msg:
+			delivered = true
+		default:
+			// This waiter already has a reply it has yet to collect
+		}
+	}
+	c.mu.Unlock()
+	if !delivered {
+		c.mux.ServeOSC(msg)
+	}
+}
+
+// Delays before reading again after an unexpected read error,
+// doubled after each error in a row up to the maximum
+const (
+	minReadBackoff = 10 * time.Millisecond
+	maxReadBackoff = 500 * time.Millisecond
+)
+
+func (c *Client) readLoop() {
+	defer close(c.done)
+	// One buffer and one message are reused for every packet
+	//     so packets nobody wants are dropped without allocating
+	buf := make([]byte, MaxPacketSize)
+	var view Message
+	backoff := time.Duration(0)
+	for {
+		n, err := c.conn.Read(buf)
+		if err == nil && n == len(buf) {
+			err = ErrPacketTruncated
+		}
+		switch {
+		case errors.Is(err, net.ErrClosed), errors.Is(err, io.EOF):
+			// The connection is gone, end the loop
+			c.err = err
+			return
+		case errors.Is(err, ErrPacketTruncated), errors.Is(err, syscall.ECONNREFUSED), isTimeout(err):
+			// A single lost packet, or the console refusing
+			//     a packet because it is not yet listening
+			continue
+		case err != nil:
+			// Any other error may repeat on every read,
+			//     so wait before trying again rather than spin
+			backoff = min(max(2*backoff, minReadBackoff), maxReadBackoff)
+			time.Sleep(backoff)
+			continue
+		}
+		backoff = 0
+		c.dispatch(buf[:n], &view)
+	}
+}
+
+func (c *Client) dispatch(packet []byte, view *Message) {
+	// Passes each message of the packet to its waiters or handlers
+	//     Malformed packets are dropped
+	//   A message is parsed in place into view, and copied into a
+	//     message of its own only if a waiter or handler will take it
+	if isBundle(packet) {
+		p, err := ParsePacket(packet)
+		if err != nil {
+			return
+		}
+		for _, msg := range p.(*Bundle).Messages() {
+			c.deliver(msg)
+		}
+		return
+	}
+	if view.ParseView(packet) != nil {
+		return
+	}
+	if !c.wanted(trimZeroBytesRight(view.Address)) {
+		return
+	}
+	var msg Message
+	msg.Packet.Write(packet)
+	if msg.ParseMessage() != nil {
+		return
+	}
+	c.deliver(msg)
+}
+
+func (c *Client) wanted(address []byte) bool {
+	// Reports whether a waiter or handler would receive a message at address
+	c.mu.Lock()
+	waiting := len(c.waiters[string(address)]) > 0
+	c.mu.Unlock()
+	return waiting || c.mux.matches(string(address))
+}
+
+func (c *Client) Done() <-chan struct{} {
+	// Returns a channel which is closed when the read loop stops
+	return c.done
+}
+
+func (c *Client) Err() error {
+	// Returns the error which stopped the read loop, if it has stopped
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+func (c *Client) Close() error {
+	// Closes the connection, which stops the read loop
+	err := c.conn.Close()
+	<-c.done
+	return err
+}
@@ -0,0 +1,147 @@
+package osc
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingConn fails every read with err until it is closed
+type failingConn struct {
+	net.Conn
+	err    error
+	reads  atomic.Int64
+	once   sync.Once
+	closed chan struct{}
+}
+
+func newFailingConn(err error) *failingConn {
+	return &failingConn{err: err, closed: make(chan struct{})}
+}
+
+func (fc *failingConn) Read(b []byte) (int, error) {
+	fc.reads.Add(1)
+	select {
+	case <-fc.closed:
+		return 0, net.ErrClosed
+	default:
+		return 0, fc.err
+	}
+}
+
+func (fc *failingConn) Close() error {
+	fc.once.Do(func() { close(fc.closed) })
+	return nil
+}
+
+func TestClientReadErrorBackoff(t *testing.T) {
+	// A persistent read error must not spin the read loop
+	conn := newFailingConn(errors.New("connection reset"))
+	c := NewClient(conn)
+	time.Sleep(200 * time.Millisecond)
+	if reads := conn.reads.Load(); reads > 20 {
+		t.Errorf("%d reads in 200ms of failing reads, want the loop to back off", reads)
+	}
+	select {
+	case <-c.Done():
+		t.Fatal("read loop ended on a read error it should retry")
+	default:
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not end the read loop")
+	}
+	if !errors.Is(c.Err(), net.ErrClosed) {
+		t.Errorf("Err = %v, want net.ErrClosed", c.Err())
+	}
+}
+
+func TestClientBadStreamFrame(t *testing.T) {
+	// A length prefix which cannot be right ends the read loop for good
+	local, remote := net.Pipe()
+	defer remote.Close()
+	c := NewClient(NewStreamConn(local, FramingLength))
+	go remote.Write([]byte{0x7f, 0xff, 0xff, 0xff})
+	select {
+	case <-c.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("read loop still running after a bad length prefix")
+	}
+	if !errors.Is(c.Err(), net.ErrClosed) {
+		t.Errorf("Err = %v, want net.ErrClosed", c.Err())
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return")
+	}
+}
+
+func newDispatchClient() *Client {
+	// A client without a read loop, fed by calling dispatch
+	return &Client{
+		mux:     NewServeMux(),
+		waiters: make(map[string][]chan Message),
+		done:    make(chan struct{}),
+	}
+}
+
+func meterPacket() []byte {
+	// A meter reply, as the console sends one every 50ms
+	src := NewMessage("/meters/1")
+	src.AddBlob(make([]byte, 4+96*4))
+	src.MakePacket()
+	return src.Bytes()
+}
+
+func BenchmarkClientDispatch(b *testing.B) {
+	packet := meterPacket()
+	b.Run("unwanted", func(b *testing.B) {
+		c := newDispatchClient()
+		c.HandleFunc("/ch/*/mix/fader", func(msg Message) {})
+		var view Message
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.dispatch(packet, &view)
+		}
+	})
+	b.Run("handled", func(b *testing.B) {
+		c := newDispatchClient()
+		c.HandleFunc("/meters/1", func(msg Message) {})
+		var view Message
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.dispatch(packet, &view)
+		}
+	})
+}
+
+func TestClientDispatchAllocs(t *testing.T) {
+	// Packets nobody waits for or handles are dropped without allocating
+	c := newDispatchClient()
+	c.HandleFunc("/ch/{01,02}/mix/fader", func(msg Message) {})
+	packet := meterPacket()
+	var view Message
+	allocs := testing.AllocsPerRun(100, func() {
+		c.dispatch(packet, &view)
+	})
+	if allocs != 0 {
+		t.Errorf("dispatch of an unwanted packet made %v allocations, want 0", allocs)
+	}
+}
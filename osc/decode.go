@@ -0,0 +1,218 @@
+package osc
+
+import (
+	"bytes"
+)
+
+// decoder walks a packet, tracking the offset for error reports.
+// In strict mode padding must be all zero bytes and nothing may follow
+// the last argument, otherwise short or dirty padding is tolerated
+type decoder struct {
+	data   []byte
+	off    int
+	strict bool
+	view   bool // leave the message referring into data rather than copying
+}
+
+func (d *decoder) errorf(typeTag byte, err error) error {
+	return &ParseError{Offset: d.off, TypeTag: typeTag, Err: err}
+}
+
+func (d *decoder) remaining() int {
+	return len(d.data) - d.off
+}
+
+func (d *decoder) readString(typeTag byte) ([]byte, error) {
+	// Reads a zero terminated string and skips its padding
+	rest := d.data[d.off:]
+	n := bytes.IndexByte(rest, 0)
+	if n < 0 {
+		if d.strict {
+			return nil, d.errorf(typeTag, ErrTruncated)
+		}
+		// Tolerate a final string missing its terminator
+		d.off = len(d.data)
+		return rest, nil
+	}
+	s := rest[:n]
+	end := n + zeroBytesToAdd(n)
+	if end > len(rest) {
+		if d.strict {
+			return nil, d.errorf(typeTag, ErrTruncated)
+		}
+		end = len(rest)
+	}
+	if d.strict && !allElementsZero(rest[n:end]) {
+		return nil, d.errorf(typeTag, ErrBadPadding)
+	}
+	d.off += end
+	return s, nil
+}
+
+func (d *decoder) readN(typeTag byte, n int) ([]byte, error) {
+	if n < 0 || n > d.remaining() {
+		return nil, d.errorf(typeTag, ErrTruncated)
+	}
+	byt := d.data[d.off : d.off+n]
+	d.off += n
+	return byt, nil
+}
+
+func (d *decoder) readBlob() ([]byte, error) {
+	// blob is "an int32 size count, followed by that many 8-bit bytes of arbitrary binary data"
+	sizeB, err := d.readN('b', 4)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.readN('b', int(bytesToInt32(sizeB)))
+	if err != nil {
+		return nil, err
+	}
+	// Skip the padding, which is absent when the blob is already aligned
+	pad := blobPadding(len(data))
+	if pad > d.remaining() {
+		if d.strict {
+			return nil, d.errorf('b', ErrTruncated)
+		}
+		pad = d.remaining()
+	}
+	if d.strict && !allElementsZero(d.data[d.off:d.off+pad]) {
+		return nil, d.errorf('b', ErrBadPadding)
+	}
+	d.off += pad
+	return data, nil
+}
+
+func (d *decoder) decodeMessage(msg *Message) error {
+	// Fills msg.Address and msg.Arguments from d.data
+	//     Unless d.view is set, argument data is copied
+	//     so msg does not refer to d.data
+	msg.Address = nil
+	if d.view {
+		msg.Arguments = msg.Arguments[:0]
+	} else {
+		msg.Arguments = nil
+	}
+	if len(d.data) == 0 {
+		return d.errorf(0, ErrTruncated)
+	}
+
+	// Read the address
+	addr, err := d.readString(0)
+	if err != nil {
+		return err
+	}
+	if d.strict && (len(addr) == 0 || addr[0] != '/') {
+		return &ParseError{Offset: 0, Err: ErrBadAddress}
+	}
+	msg.Address = addr
+	if !d.view {
+		msg.Address = bytes.Clone(addr)
+	}
+
+	// A message with only an address has no type tags
+	if d.remaining() == 0 {
+		return nil
+	}
+	if d.data[d.off] != ',' {
+		if d.strict {
+			return d.errorf(0, ErrTrailingData)
+		}
+		return nil
+	}
+
+	// Read the type tags, skipping the leading comma
+	typeTags, err := d.readString(0)
+	if err != nil {
+		return err
+	}
+	typeTags = typeTags[1:]
+
+	// Read each argument using the type tags as a map
+	if !d.view {
+		msg.Arguments = make([]argument, 0, len(typeTags))
+	}
+	for _, typeTag := range typeTags {
+		var byt []byte
+		switch typeTag {
+		case 's', 'S':
+			byt, err = d.readString(typeTag)
+		case 'b':
+			byt, err = d.readBlob()
+		default:
+			size, ok := argumentSize[typeTag]
+			if !ok {
+				// Without its size, no later argument can be found
+				return d.errorf(typeTag, ErrUnknownTypeTag)
+			}
+			byt, err = d.readN(typeTag, size)
+		}
+		if err != nil {
+			return err
+		}
+		if d.view {
+			msg.Arguments = append(msg.Arguments, argument{TypeTag: typeTag, Data: byt})
+			continue
+		}
+		byt = bytes.Clone(byt)
+		msg.Arguments = append(msg.Arguments, argument{
+			TypeTag: typeTag,
+			Data:    byt,
+			Decoded: decodeArgument(byt, typeTag),
+		})
+	}
+
+	if d.strict && d.remaining() > 0 {
+		return d.errorf(0, ErrTrailingData)
+	}
+	return nil
+}
+
+func (d *decoder) decodeBundle(b *Bundle) error {
+	// Fills b.Timetag and b.Elements from d.data
+	b.Elements = nil
+
+	// Check for the "#bundle" string
+	head, err := d.readN(0, len(bundleTag))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(head, bundleTag) {
+		return &ParseError{Offset: 0, Err: ErrBadAddress}
+	}
+
+	// Read the time tag
+	tt, err := d.readN('t', 8)
+	if err != nil {
+		return err
+	}
+	b.Timetag = Timetag(bytesToUint64(tt))
+
+	// Read each element until the packet is exhausted
+	for d.remaining() > 0 {
+		sizeB, err := d.readN(0, 4)
+		if err != nil {
+			return err
+		}
+		size := int(bytesToInt32(sizeB))
+		if d.strict && size%4 != 0 {
+			return d.errorf(0, ErrBadPadding)
+		}
+		start := d.off
+		byt, err := d.readN(0, size)
+		if err != nil {
+			return err
+		}
+		elem, err := parsePacket(byt, d.strict)
+		if err != nil {
+			// Report the offset within the whole bundle
+			if pe, ok := err.(*ParseError); ok {
+				pe.Offset += start
+			}
+			return err
+		}
+		b.Elements = append(b.Elements, elem)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package osc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors reported while parsing a packet, wrapped in a *ParseError
+var (
+	// ErrTruncated means the packet ended before the parse was complete
+	ErrTruncated = errors.New("unexpected end of packet")
+	// ErrBadPadding means a string, blob or bundle element was not correctly padded with zero bytes
+	ErrBadPadding = errors.New("bad padding")
+	// ErrUnknownTypeTag means a type tag was not recognised, so no later argument can be located
+	ErrUnknownTypeTag = errors.New("unknown type tag")
+	// ErrBadAddress means the address does not begin with '/'
+	ErrBadAddress = errors.New("address does not begin with '/'")
+	// ErrTrailingData means there were bytes after the last argument
+	ErrTrailingData = errors.New("data after last argument")
+)
+
+// ErrNoArguments is returned when reading an argument a message does not have
+var ErrNoArguments = errors.New("no arguments")
+
+// ParseError reports where and why a packet could not be parsed
+type ParseError struct {
+	Offset  int  // byte offset into the packet at which the problem was found
+	TypeTag byte // type tag of the argument being parsed, zero if none
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	if e.TypeTag != 0 {
+		return fmt.Sprintf("cannot parse '%c' argument at byte %d: %v", e.TypeTag, e.Offset, e.Err)
+	}
+	return fmt.Sprintf("cannot parse packet at byte %d: %v", e.Offset, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ArgumentTypeError is returned when an argument does not hold the requested type
+type ArgumentTypeError struct {
+	Index int
+	Want  byte // type tag requested
+	Got   byte // type tag of the argument
+}
+
+func (e *ArgumentTypeError) Error() string {
+	return fmt.Sprintf("argument %d is '%c', not '%c'", e.Index, e.Got, e.Want)
+}
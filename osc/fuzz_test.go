@@ -0,0 +1,258 @@
+package osc
+
+import (
+	"bytes"
+	"image/color"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func addGoldenSeeds(f *testing.F) {
+	for _, tc := range goldenPackets {
+		f.Add(goldenBytes(f, tc.packet))
+	}
+}
+
+func FuzzParsePacket(f *testing.F) {
+	addGoldenSeeds(f)
+	f.Add([]byte("#bundle\x00\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x08/a\x00\x00,\x00\x00\x00"))
+	f.Fuzz(func(t *testing.T, packet []byte) {
+		// Neither mode may panic on any input
+		ParsePacket(packet)
+		p, err := ParsePacketStrict(packet)
+		if err != nil {
+			return
+		}
+		// A packet accepted in strict mode must encode again to a packet
+		//     which is also accepted, and which encodes to itself
+		byt, err := p.AppendPacket(nil)
+		if err != nil {
+			t.Fatalf("AppendPacket of a strictly parsed packet: %v", err)
+		}
+		again, err := ParsePacketStrict(byt)
+		if err != nil {
+			t.Fatalf("re-encoded %x does not parse: %v", byt, err)
+		}
+		byt2, _ := again.AppendPacket(nil)
+		if !bytes.Equal(byt, byt2) {
+			t.Fatalf("encoding not stable: %x then %x", byt, byt2)
+		}
+		// The only change allowed is dropping the empty type tag string ","
+		//     from a message without arguments
+		if msg, ok := p.(*Message); ok && len(msg.Arguments) > 0 && !bytes.Equal(byt, packet) {
+			t.Fatalf("re-encoded %x, want %x", byt, packet)
+		}
+	})
+}
+
+func FuzzParseView(f *testing.F) {
+	addGoldenSeeds(f)
+	f.Fuzz(func(t *testing.T, packet []byte) {
+		// ParseView must find the same message as ParseMessage, without copying
+		var msg, view Message
+		msg.Packet.Write(packet)
+		err := msg.ParseMessage()
+		viewErr := view.ParseView(packet)
+		if (err == nil) != (viewErr == nil) {
+			t.Fatalf("ParseMessage err = %v, ParseView err = %v", err, viewErr)
+		}
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(msg.Address, view.Address) || len(msg.Arguments) != len(view.Arguments) {
+			t.Fatalf("ParseView = %s, ParseMessage = %s", view.Format(), msg.Format())
+		}
+		for i := range msg.Arguments {
+			if msg.Arguments[i].TypeTag != view.Arguments[i].TypeTag ||
+				!bytes.Equal(msg.Arguments[i].Data, view.Arguments[i].Data) {
+				t.Fatalf("argument %d differs: %s, %s", i, view.Format(), msg.Format())
+			}
+		}
+	})
+}
+
+func FuzzParseText(f *testing.F) {
+	for _, tc := range goldenPackets {
+		f.Add(tc.text)
+	}
+	f.Add(`/x ,sc[T] "a b" 'z'`)
+	f.Fuzz(func(t *testing.T, text string) {
+		msg, err := ParseText(text)
+		if err != nil {
+			return
+		}
+		// Formatting and parsing again must give the same packet
+		want, err := msg.AppendPacket(nil)
+		if err != nil {
+			return
+		}
+		again, err := ParseText(msg.Format())
+		if err != nil {
+			t.Fatalf("ParseText(%q) of Format: %v", msg.Format(), err)
+		}
+		got, err := again.AppendPacket(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("%q: round trip through %q gave %x, want %x", text, msg.Format(), got, want)
+		}
+	})
+}
+
+func randomMessage(r *rand.Rand) Message {
+	// Builds a message with a random address and arguments of every type
+	randomBytes := func(n int) []byte {
+		byt := make([]byte, n)
+		r.Read(byt)
+		return byt
+	}
+	randomString := func() string {
+		// Any bytes but zero, which terminates a string
+		byt := randomBytes(r.Intn(12))
+		for i := range byt {
+			byt[i] = byte(1 + r.Intn(255))
+		}
+		return string(byt)
+	}
+
+	msg := NewMessage("/" + randomString())
+	for n := r.Intn(10); n > 0; n-- {
+		switch r.Intn(16) {
+		case 0:
+			msg.AddInt(r.Int31())
+		case 1:
+			msg.AddFloat(r.Float32())
+		case 2:
+			msg.AddString(randomString())
+		case 3:
+			msg.AddBlob(randomBytes(r.Intn(9)))
+		case 4:
+			msg.AddInt64(r.Int63())
+		case 5:
+			msg.AddDouble(r.NormFloat64())
+		case 6:
+			msg.AddTimetag(Timetag(r.Uint64()))
+		case 7:
+			msg.AddSymbol(randomString())
+		case 8:
+			msg.AddChar(Char('a' + r.Intn(26)))
+		case 9:
+			msg.AddRGBA(color.RGBA{R: uint8(r.Intn(256)), A: 255})
+		case 10:
+			msg.AddMIDI(MIDI{Status: 0x90, Data1: 60, Data2: 100})
+		case 11:
+			msg.AddBool(r.Intn(2) == 0)
+		case 12:
+			msg.AddNil()
+		case 13:
+			msg.AddImpulse()
+		case 14:
+			msg.BeginArray()
+			msg.AddInt(1)
+			msg.EndArray()
+		case 15:
+			msg.AddBlob(nil)
+		}
+	}
+	return msg
+}
+
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		msg := randomMessage(r)
+		if err := msg.MakePacket(); err != nil {
+			t.Fatal(err)
+		}
+		packet := msg.Bytes()
+		if len(packet)%4 != 0 {
+			t.Fatalf("%s: packet of %d bytes is not a multiple of 4", msg.Format(), len(packet))
+		}
+
+		var parsed Message
+		parsed.Packet.Write(packet)
+		if err := parsed.ParseMessageStrict(); err != nil {
+			t.Fatalf("%s: %v", msg.Format(), err)
+		}
+		if !bytes.Equal(parsed.Address, msg.Address) || len(parsed.Arguments) != len(msg.Arguments) {
+			t.Fatalf("parsed %s, want %s", parsed.Format(), msg.Format())
+		}
+		for j, arg := range msg.Arguments {
+			got := parsed.Arguments[j]
+			if got.TypeTag != arg.TypeTag || !reflect.DeepEqual(got.Decoded, arg.Decoded) && len(arg.Data) > 0 {
+				t.Fatalf("argument %d: parsed %c %#v, want %c %#v", j, got.TypeTag, got.Decoded, arg.TypeTag, arg.Decoded)
+			}
+		}
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	inner := NewBundle(Immediately)
+	inner.AddMessage(randomMessage(r))
+	outer := NewBundle(Timetag(r.Uint64()))
+	outer.AddMessage(randomMessage(r))
+	outer.AddBundle(inner)
+	outer.AddMessage(randomMessage(r))
+	if err := outer.MakePacket(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := ParsePacketStrict(outer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := p.(*Bundle)
+	if parsed.Timetag != outer.Timetag || len(parsed.Elements) != 3 {
+		t.Fatalf("parsed timetag %x with %d elements", parsed.Timetag, len(parsed.Elements))
+	}
+	want := outer.Messages()
+	got := parsed.Messages()
+	for i := range want {
+		if got[i].Format() != want[i].Format() {
+			t.Errorf("message %d = %s, want %s", i, got[i].Format(), want[i].Format())
+		}
+	}
+}
+
+func TestPadding(t *testing.T) {
+	for n := 0; n < 16; n++ {
+		// Strings always gain one to four zero bytes
+		if z := zeroBytesToAdd(n); z < 1 || z > 4 || (n+z)%4 != 0 {
+			t.Errorf("zeroBytesToAdd(%d) = %d", n, z)
+		}
+		// Blobs gain zero to three
+		if z := blobPadding(n); z < 0 || z > 3 || (n+z)%4 != 0 {
+			t.Errorf("blobPadding(%d) = %d", n, z)
+		}
+		s := bytes.Repeat([]byte{'a'}, n)
+		fixed := fixZeroBytes(s)
+		if len(fixed)%4 != 0 || !bytes.Equal(trimZeroBytesRight(fixed), s) || fixed[len(fixed)-1] != 0 {
+			t.Errorf("fixZeroBytes(%q) = %q", s, fixed)
+		}
+	}
+}
+
+func FuzzStreamConn(f *testing.F) {
+	f.Add([]byte{slipEnd, '/', 'a', 0, 0, slipEsc, slipEscEnd, slipEnd}, true)
+	f.Add([]byte{0, 0, 0, 4, '/', 'a', 0, 0}, false)
+	f.Fuzz(func(t *testing.T, stream []byte, slip bool) {
+		// Reading frames from a corrupt stream must end in an error, not a panic
+		framing := FramingLength
+		if slip {
+			framing = FramingSLIP
+		}
+		sc := NewStreamConn(readerConn{Reader: bytes.NewReader(stream)}, framing)
+		buf := make([]byte, 64)
+		for i := 0; i <= len(stream); i++ {
+			n, err := sc.Read(buf)
+			if err != nil && err != ErrPacketTruncated {
+				return
+			}
+			ParsePacket(buf[:n])
+		}
+		t.Fatal("more frames than bytes in the stream")
+	})
+}
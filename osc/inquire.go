@@ -0,0 +1,117 @@
+package osc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// InquireOptions controls how InquireContext waits for a reply
+type InquireOptions struct {
+	Timeout      time.Duration // time to wait for each attempt, DefaultInquireTimeout if zero
+	Retries      int           // number of times to resend the message after a timeout
+	ReplyAddress string        // address the reply must have, the request's address if empty
+}
+
+const DefaultInquireTimeout = 500 * time.Millisecond
+
+// ErrNoReply is returned when every attempt of an inquiry timed out
+var ErrNoReply = errors.New("no reply")
+
+func InquireContext(ctx context.Context, conn net.Conn, msg Message, opts InquireOptions) (reply Message, err error) {
+	// Sends the message and waits for a reply with a matching address
+	//   Replies to other addresses, such as late replies to an earlier
+	//     request, and unparsable packets are discarded
+	//   If no reply arrives within opts.Timeout the message is sent again,
+	//     up to opts.Retries more times
+	//   Returns ctx.Err() if the context is done first
+	if conn == nil {
+		return reply, errors.New("no connection made")
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultInquireTimeout
+	}
+	want := opts.ReplyAddress
+	if want == "" {
+		want = string(trimZeroBytesRight(msg.Address))
+	}
+
+	// Unblock any read in progress as soon as the context is done
+	unblocked := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetReadDeadline(time.Now())
+		close(unblocked)
+	})
+	defer func() {
+		// Leave the conn without a deadline for the next caller
+		//     If the AfterFunc has already started, wait for it to finish
+		//     so its deadline cannot land after this reset
+		if !stop() {
+			<-unblocked
+		}
+		conn.SetReadDeadline(time.Time{})
+	}()
+
+	for attempt := 0; attempt <= opts.Retries; attempt++ {
+		if err = ctx.Err(); err != nil {
+			return reply, err
+		}
+		err = Send(conn, msg)
+		if err != nil {
+			return reply, err
+		}
+
+		// The attempt ends at its timeout or the context deadline, whichever is first
+		deadline := time.Now().Add(timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		conn.SetReadDeadline(deadline)
+		if err = ctx.Err(); err != nil {
+			return reply, err
+		}
+
+		reply, err = listenFor(conn, want)
+		if err == nil {
+			return reply, nil
+		}
+		if ctx.Err() != nil {
+			return reply, ctx.Err()
+		}
+		if !isTimeout(err) {
+			return reply, err
+		}
+		// The read deadline may pass a moment before the context notices its own
+		if d, ok := ctx.Deadline(); ok && !time.Now().Before(d) {
+			return reply, context.DeadlineExceeded
+		}
+	}
+
+	return reply, ErrNoReply
+}
+
+func listenFor(conn net.Conn, address string) (reply Message, err error) {
+	// Reads messages until one arrives with the given address
+	for {
+		byt, release, err := readPacket(conn)
+		if err != nil {
+			return reply, err
+		}
+		reply = Message{}
+		reply.Packet.Write(byt)
+		release()
+		if reply.ParseMessage() != nil {
+			continue
+		}
+		if string(trimZeroBytesRight(reply.Address)) == address {
+			return reply, nil
+		}
+	}
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
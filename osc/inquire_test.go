@@ -0,0 +1,79 @@
+package osc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func startEchoServer(t *testing.T) string {
+	// Serves on loopback, answering every message but /silent
+	//     with a message of the same address
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		Handler: PacketHandlerFunc(func(p Packet, from Peer) {
+			msg, ok := p.(*Message)
+			if !ok || string(msg.Address) == "/silent" {
+				return
+			}
+			from.Send(NewMessage(string(msg.Address)))
+		}),
+	}
+	go s.Serve(conn)
+	t.Cleanup(func() { s.Close() })
+	return conn.LocalAddr().String()
+}
+
+func TestInquireContextSharedConn(t *testing.T) {
+	conn, err := net.Dial("udp", startEchoServer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	inquireInfo := func() {
+		t.Helper()
+		reply, err := InquireContext(context.Background(), conn, NewMessage("/info"), InquireOptions{Timeout: time.Second})
+		if err != nil {
+			t.Fatalf("reply: %v", err)
+		}
+		if string(reply.Address) != "/info" {
+			t.Fatalf("reply from %s, want /info", reply.Address)
+		}
+	}
+
+	// Each inquiry must leave the conn usable by the next
+	for i := 0; i < 10; i++ {
+		// Cancelled while waiting
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(time.Duration(i)*time.Millisecond, cancel)
+		_, err := InquireContext(ctx, conn, NewMessage("/silent"), InquireOptions{Timeout: time.Second})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("cancel: got %v, want context.Canceled", err)
+		}
+		cancel()
+		inquireInfo()
+
+		// Timed out
+		_, err = InquireContext(context.Background(), conn, NewMessage("/silent"), InquireOptions{Timeout: 10 * time.Millisecond, Retries: 1})
+		if !errors.Is(err, ErrNoReply) {
+			t.Fatalf("timeout: got %v, want ErrNoReply", err)
+		}
+		inquireInfo()
+
+		// Context deadline before the attempt's timeout
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Millisecond)
+		_, err = InquireContext(ctx, conn, NewMessage("/silent"), InquireOptions{Timeout: time.Second})
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("deadline: got %v, want context.DeadlineExceeded", err)
+		}
+		inquireInfo()
+	}
+}
@@ -0,0 +1,158 @@
+package osc
+
+import (
+	"strings"
+)
+
+func Match(pattern, address string) bool {
+	// Reports whether the OSC address matches the OSC address pattern
+	//     Each part of the address between slashes is matched separately
+	//     so wildcards never match across a '/'
+	//   Supported pattern syntax:
+	//     ?           any single character
+	//     *           any sequence of zero or more characters
+	//     [abc] [a-z] any character in the list or range, [!a-z] negates
+	//     {foo,bar}   any of the comma separated strings
+	//     //          any number of parts, as in OSC 1.1, e.g. "//fader"
+	p, a := addressParts{s: pattern}, addressParts{s: address}
+	// Drop the empty part before the leading slash of both
+	//     An address without one, such as the X32's "node" reply,
+	//     matches only a pattern without one
+	patternFirst, patternRest := p.next()
+	addressFirst, addressRest := a.next()
+	if (patternFirst == "") != (addressFirst == "") {
+		return false
+	}
+	if patternFirst == "" {
+		p, a = patternRest, addressRest
+	}
+	return matchParts(p, a)
+}
+
+// addressParts walks the parts of an address between slashes
+// without splitting it, so matching does not allocate
+type addressParts struct {
+	s   string
+	end bool // no parts are left
+}
+
+func (ap addressParts) next() (part string, rest addressParts) {
+	part, tail, found := strings.Cut(ap.s, "/")
+	return part, addressParts{s: tail, end: !found}
+}
+
+func matchParts(p, a addressParts) bool {
+	if p.end {
+		return a.end
+	}
+	part, rest := p.next()
+	// An empty part followed by another comes from "//",
+	//     which skips any number of address parts
+	//   An empty last part comes from a trailing slash,
+	//     and matches only an address with one too
+	if part == "" && !rest.end {
+		for {
+			if matchParts(rest, a) {
+				return true
+			}
+			if a.end {
+				return false
+			}
+			_, a = a.next()
+		}
+	}
+	if a.end {
+		return false
+	}
+	addressPart, addressRest := a.next()
+	if !matchPart(part, addressPart) {
+		return false
+	}
+	return matchParts(rest, addressRest)
+}
+
+func isPattern(s string) bool {
+	// True if s contains any of the OSC pattern characters
+	return strings.ContainsAny(s, "*?[]{}") || strings.Contains(s, "//")
+}
+
+func matchPart(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// Collapse consecutive stars, then try every possible split of s
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchPart(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		case '[':
+			end := strings.IndexByte(pattern, ']')
+			if end < 0 || len(s) == 0 {
+				return false
+			}
+			if !matchList(pattern[1:end], s[0]) {
+				return false
+			}
+			pattern, s = pattern[end+1:], s[1:]
+		case '{':
+			end := strings.IndexByte(pattern, '}')
+			if end < 0 {
+				return false
+			}
+			rest := pattern[end+1:]
+			alts, more := pattern[1:end], true
+			for more {
+				var alt string
+				alt, alts, more = strings.Cut(alts, ",")
+				if strings.HasPrefix(s, alt) && matchPart(rest, s[len(alt):]) {
+					return true
+				}
+			}
+			return false
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+func matchList(list string, c byte) bool {
+	// Matches c against the inside of a [] expression
+	//     A leading '!' negates the list
+	//     A '-' between two characters is a range
+	negate := false
+	if len(list) > 0 && list[0] == '!' {
+		negate = true
+		list = list[1:]
+	}
+	matched := false
+	for i := 0; i < len(list); i++ {
+		if i+2 < len(list) && list[i+1] == '-' {
+			if list[i] <= c && c <= list[i+2] {
+				matched = true
+			}
+			i += 2
+			continue
+		}
+		if list[i] == c {
+			matched = true
+		}
+	}
+	return matched != negate
+}
@@ -0,0 +1,169 @@
+package osc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		address string
+		want    bool
+	}{
+		{"/ch/01/mix/fader", "/ch/01/mix/fader", true},
+		{"/ch/01/mix/fader", "/ch/02/mix/fader", false},
+		{"/ch/01/mix", "/ch/01/mix/fader", false},
+		{"/ch/01/mix/fader", "/ch/01/mix", false},
+
+		// ?
+		{"/ch/0?/mix/fader", "/ch/07/mix/fader", true},
+		{"/ch/0?/mix/fader", "/ch/17/mix/fader", false},
+		{"/ch/0?/mix/fader", "/ch/0/mix/fader", false},
+
+		// *
+		{"/ch/*/mix/fader", "/ch/01/mix/fader", true},
+		{"/ch/*/mix/fader", "/ch//mix/fader", true},
+		{"/ch/*", "/ch/01/mix/fader", false},
+		{"/*/01/mix/*", "/bus/01/mix/on", true},
+		{"/ch/0*1/mix/fader", "/ch/01/mix/fader", true},
+		{"/ch/0**1/mix/fader", "/ch/0221/mix/fader", true},
+		{"/ch/*2/mix/fader", "/ch/01/mix/fader", false},
+
+		// [] lists and ranges
+		{"/ch/0[1-4]/mix/on", "/ch/03/mix/on", true},
+		{"/ch/0[1-4]/mix/on", "/ch/05/mix/on", false},
+		{"/ch/0[135]/mix/on", "/ch/05/mix/on", true},
+		{"/ch/0[135]/mix/on", "/ch/02/mix/on", false},
+		{"/ch/0[!1-4]/mix/on", "/ch/05/mix/on", true},
+		{"/ch/0[!1-4]/mix/on", "/ch/02/mix/on", false},
+		{"/ch/0[1-4/mix/on", "/ch/03/mix/on", false},
+
+		// {} alternatives
+		{"/ch/{01,02}/mix/on", "/ch/02/mix/on", true},
+		{"/ch/{01,02}/mix/on", "/ch/03/mix/on", false},
+		{"/{ch,bus}/01/mix/on", "/bus/01/mix/on", true},
+		{"/main/{st,m}/mix/fader", "/main/m/mix/fader", true},
+		{"/ch/{01,02/mix/on", "/ch/01/mix/on", false},
+
+		// Wildcards do not match across a slash
+		{"/ch/*", "/ch/01/mix", false},
+		{"/ch/?", "/ch/a/b", false},
+
+		// // skips any number of parts
+		{"//fader", "/ch/01/mix/fader", true},
+		{"//fader", "/fader", true},
+		{"//fader", "/ch/01/mix/on", false},
+		{"//*", "/ch/01/mix/fader", true},
+		{"/ch//fader", "/ch/01/mix/fader", true},
+		{"/ch//fader", "/ch/fader", true},
+		{"/ch//fader", "/bus/01/mix/fader", false},
+		{"/ch//", "/ch/01/mix/fader", false},
+		{"/ch//", "/ch/01/", true},
+
+		// A trailing slash is an empty last part, not //
+		{"/ch/", "/ch/01/mix/fader", false},
+		{"/ch/", "/ch", false},
+		{"/ch/", "/ch/", true},
+		{"/", "/ch/01/mix/fader", false},
+		{"/", "/", true},
+
+		// A leading slash must be on both or neither
+		{"node", "node", true},
+		{"/node", "node", false},
+		{"node", "/node", false},
+		{"//node", "node", false},
+		{"n*", "node", true},
+	}
+	for _, tc := range tests {
+		if got := Match(tc.pattern, tc.address); got != tc.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tc.pattern, tc.address, got, tc.want)
+		}
+	}
+}
+
+func TestServeMuxDispatch(t *testing.T) {
+	mux := NewServeMux()
+	var got []string
+	record := func(name string) func(msg Message) {
+		return func(msg Message) {
+			got = append(got, name+" "+string(msg.Address))
+		}
+	}
+	mux.HandleFunc("/ch/01/mix/fader", record("exact"))
+	mux.HandleFunc("/ch/*/mix/fader", record("any ch"))
+	mux.HandleFunc("/bus/*/mix/fader", record("any bus"))
+
+	tests := []struct {
+		name    string
+		packet  Packet
+		handled bool
+		want    []string
+	}{
+		{
+			name:    "several handlers",
+			packet:  &Message{Address: []byte("/ch/01/mix/fader")},
+			handled: true,
+			want:    []string{"exact /ch/01/mix/fader", "any ch /ch/01/mix/fader"},
+		},
+		{
+			name:    "one handler",
+			packet:  &Message{Address: []byte("/ch/02/mix/fader")},
+			handled: true,
+			want:    []string{"any ch /ch/02/mix/fader"},
+		},
+		{
+			name:    "no match",
+			packet:  &Message{Address: []byte("/mtx/01/mix/fader")},
+			handled: false,
+		},
+		{
+			name:    "pattern address",
+			packet:  &Message{Address: []byte("/ch/0[1-2]/mix/fader")},
+			handled: true,
+			want:    []string{"exact /ch/0[1-2]/mix/fader", "any ch /ch/0[1-2]/mix/fader"},
+		},
+		{
+			name: "bundle",
+			packet: &Bundle{Elements: []Packet{
+				&Message{Address: []byte("/bus/01/mix/fader")},
+				&Message{Address: []byte("/ch/04/mix/fader")},
+			}},
+			handled: true,
+			want:    []string{"any bus /bus/01/mix/fader", "any ch /ch/04/mix/fader"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got = nil
+			if handled := mux.Dispatch(tc.packet); handled != tc.handled {
+				t.Errorf("Dispatch = %v, want %v", handled, tc.handled)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("handlers called %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	mux.Remove("/ch/*/mix/fader")
+	got = nil
+	mux.Dispatch(&Message{Address: []byte("/ch/01/mix/fader")})
+	if want := []string{"exact /ch/01/mix/fader"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after Remove, handlers called %q, want %q", got, want)
+	}
+
+	// Removing by handle leaves other handlers on the same pattern
+	remove := mux.HandleFunc("/ch/01/mix/fader", record("second"))
+	got = nil
+	mux.Dispatch(&Message{Address: []byte("/ch/01/mix/fader")})
+	if want := []string{"exact /ch/01/mix/fader", "second /ch/01/mix/fader"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("handlers called %q, want %q", got, want)
+	}
+	remove()
+	remove()
+	got = nil
+	mux.Dispatch(&Message{Address: []byte("/ch/01/mix/fader")})
+	if want := []string{"exact /ch/01/mix/fader"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after remove, handlers called %q, want %q", got, want)
+	}
+}
@@ -2,9 +2,8 @@ package osc
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
-	"io"
+	"image/color"
 )
 
 type Message struct {
@@ -19,6 +18,28 @@ type argument struct {
 	Decoded any
 }
 
+// Char is an OSC 'c' argument, an ascii character sent as 32 bits
+type Char rune
+
+// MIDI is an OSC 'm' argument, a 4 byte MIDI message
+type MIDI struct {
+	Port   byte
+	Status byte
+	Data1  byte
+	Data2  byte
+}
+
+// Impulse is the decoded value of an OSC 'I' argument, which carries no data
+type Impulse struct{}
+
+// Byte length of each argument type whose size is fixed.
+// Strings and blobs are not listed as their size varies
+var argumentSize = map[byte]int{
+	'i': 4, 'f': 4, 'c': 4, 'r': 4, 'm': 4,
+	'h': 8, 'd': 8, 't': 8,
+	'T': 0, 'F': 0, 'N': 0, 'I': 0, '[': 0, ']': 0,
+}
+
 func NewMessage(addr string) Message {
 	msg := Message{
 		Address: []byte(addr),
@@ -26,59 +47,57 @@ func NewMessage(addr string) Message {
 	return msg
 }
 
-func (msg *Message) MakePacket() (err error) {
+func (msg *Message) MakePacket() error {
 	// Ensure msg.Packet is empty
 	msg.Packet.Reset()
 
-	// Ensure correct count of zero bytes appended to address, and write to packet
-	// Each part of an OSC Message must be divisible by 4 bytes,
-	//     and if it already is, it must be padded with 4 more zero bytes
-	_, err = msg.Packet.Write(fixZeroBytes(msg.Address))
+	// Encode straight into the space the buffer already has
+	byt, err := msg.AppendPacket(msg.Packet.AvailableBuffer())
 	if err != nil {
 		return err
 	}
+	msg.Packet.Write(byt)
+	return nil
+}
 
-	// If there are arguments, write the type tags to the packet with a leading comma
-	if len(msg.Arguments) > 0 {
-		if err := msg.Packet.WriteByte(','); err != nil {
-			return err
-		}
-	}
-	for _, arg := range msg.Arguments {
-		if err := msg.Packet.WriteByte(arg.TypeTag); err != nil {
-			return err
-		}
-	}
+func (msg *Message) AppendPacket(dst []byte) ([]byte, error) {
+	// Appends the encoded message to dst and returns the extended slice
+	//     Nothing is allocated when dst has enough capacity
+
+	// Ensure correct count of zero bytes appended to address
+	// Each part of an OSC Message must be divisible by 4 bytes,
+	//     and if it already is, it must be padded with 4 more zero bytes
+	dst = appendPadded(dst, trimZeroBytes(msg.Address))
+
+	// If there are arguments, write the type tags with a leading comma
 	if len(msg.Arguments) > 0 {
-		zerosToAdd := zeroBytesToAdd(len(msg.Arguments) + 1)
-		for i := 0; i < zerosToAdd; i++ {
-			msg.Packet.WriteByte(0)
+		dst = append(dst, ',')
+		for _, arg := range msg.Arguments {
+			dst = append(dst, arg.TypeTag)
 		}
+		dst = append(dst, zeroPad[:zeroBytesToAdd(len(msg.Arguments)+1)]...)
 	}
 
 	// For each argument, write to packet
 	for _, arg := range msg.Arguments {
 		switch arg.TypeTag {
 		// String should be suffixed with correct count of zero bytes
-		case 's':
-			arg.Data = fixZeroBytes(arg.Data)
-		// Int32 and Float32 should always be of length 4 bytes
-		case 'i':
-			if len(arg.Data) != 4 {
-				return fmt.Errorf("int32 not of length 4 bytes")
-			}
-		case 'f':
-			if len(arg.Data) != 4 {
-				return fmt.Errorf("float32 not of length 4 bytes")
+		case 's', 'S':
+			dst = appendPadded(dst, trimZeroBytes(arg.Data))
+		// Blob is prefixed with its size and padded to a multiple of 4 bytes
+		case 'b':
+			dst = AppendBlob(dst, arg.Data)
+		default:
+			// Every other type has a fixed length
+			size, ok := argumentSize[arg.TypeTag]
+			if ok && len(arg.Data) != size {
+				return dst, fmt.Errorf("argument '%c' not of length %d bytes", arg.TypeTag, size)
 			}
-		}
-		_, err = msg.Packet.Write(arg.Data)
-		if err != nil {
-			return err
+			dst = append(dst, arg.Data...)
 		}
 	}
 
-	return nil
+	return dst, nil
 }
 
 func (msg *Message) AddString(s string) {
@@ -105,152 +124,227 @@ func (msg *Message) AddFloat(x float32) {
 			Decoded: x})
 }
 
-func (msg *Message) ParseMessage() (err error) {
-	// Parses an OSC message in the form of []byte into a message type as defined above
+func (msg *Message) AddBlob(b []byte) {
+	msg.Arguments = append(msg.Arguments,
+		argument{
+			TypeTag: 'b',
+			Data:    b,
+			Decoded: b})
+}
 
-	// Read into msg.Address until the comma byte
-	msg.Address, err = msg.Packet.ReadBytes(byte(','))
-	// If EOF, return with no error
-	if err == io.EOF {
-		return nil
-	}
-	if err != nil {
-		return err
-	}
+func (msg *Message) AddInt64(x int64) {
+	msg.Arguments = append(msg.Arguments,
+		argument{
+			TypeTag: 'h',
+			Data:    uint64ToBytes(uint64(x)),
+			Decoded: x})
+}
 
-	// Remove the comma byte
-	msg.Address = bytes.TrimSuffix(msg.Address, []byte(","))
+func (msg *Message) AddDouble(x float64) {
+	msg.Arguments = append(msg.Arguments,
+		argument{
+			TypeTag: 'd',
+			Data:    float64ToBytes(x),
+			Decoded: x})
+}
 
-	// Remove any trailing zero bytes
-	//     which may have been read before the comma byte
-	msg.Address = trimZeroBytesRight(msg.Address)
+func (msg *Message) AddTimetag(tt Timetag) {
+	msg.Arguments = append(msg.Arguments,
+		argument{
+			TypeTag: 't',
+			Data:    uint64ToBytes(uint64(tt)),
+			Decoded: tt})
+}
 
-	// For each type tag, append a new argument
-	var typeTag byte
-	for {
-		typeTag, err = msg.Packet.ReadByte()
-		if err != nil {
-			return err
-		}
-		// When we hit a zero byte, break the loop
-		if typeTag == byte(0) {
-			break
-		}
-		msg.Arguments = append(msg.Arguments, argument{TypeTag: typeTag})
-	}
+func (msg *Message) AddSymbol(s string) {
+	msg.Arguments = append(msg.Arguments,
+		argument{
+			TypeTag: 'S',
+			Data:    []byte(s),
+			Decoded: s})
+}
 
-	// Unread the zero byte
-	msg.Packet.UnreadByte()
-
-	// Skip the zero bytes which trail the typetags portion
-	//    Dont skip too many zero bytes
-	//     as some could be used in a following float32 osc argument
-	// Find the length of msg.Arguments plus one (the removed comma)
-	// Find the number of zero bytes that should trail the type tags
-	// Skip those zero bytes
-	zerosToSkip := zeroBytesToAdd(len(msg.Arguments) + 1)
-	_, err = msg.Packet.Read(make([]byte, zerosToSkip))
-	// Only return if the err is not io.EOF
-	if err != nil && !errors.Is(err, io.EOF) {
-		return err
+func (msg *Message) AddChar(c Char) {
+	msg.Arguments = append(msg.Arguments,
+		argument{
+			TypeTag: 'c',
+			Data:    int32ToBytes(int32(c)),
+			Decoded: c})
+}
+
+func (msg *Message) AddRGBA(c color.RGBA) {
+	msg.Arguments = append(msg.Arguments,
+		argument{
+			TypeTag: 'r',
+			Data:    []byte{c.R, c.G, c.B, c.A},
+			Decoded: c})
+}
+
+func (msg *Message) AddMIDI(m MIDI) {
+	msg.Arguments = append(msg.Arguments,
+		argument{
+			TypeTag: 'm',
+			Data:    []byte{m.Port, m.Status, m.Data1, m.Data2},
+			Decoded: m})
+}
+
+func (msg *Message) AddBool(b bool) {
+	// True and False carry no data, the type tag is the value
+	typeTag := byte('F')
+	if b {
+		typeTag = 'T'
 	}
+	msg.Arguments = append(msg.Arguments,
+		argument{
+			TypeTag: typeTag,
+			Decoded: b})
+}
 
-	// Continue to read the osc arguments using the type tags as a map
-	//     For each type tag:
-	//         read the following bytes and append to msg.Arguments
-	for i := range msg.Arguments {
-		switch msg.Arguments[i].TypeTag {
-		case 'i', 'f':
-			// In the case of a float32 or int32, read 4 bytes
-			byt := make([]byte, 4)
-			_, err := msg.Packet.Read(byt)
-			if err != nil {
-				return err
-			}
+func (msg *Message) AddNil() {
+	msg.Arguments = append(msg.Arguments,
+		argument{
+			TypeTag: 'N',
+			Decoded: nil})
+}
 
-			// Set the data and decoded data for each argument
-			msg.Arguments[i].Data = byt
-			msg.Arguments[i].Decoded = decodeArgument(byt, msg.Arguments[i].TypeTag)
+func (msg *Message) AddImpulse() {
+	msg.Arguments = append(msg.Arguments,
+		argument{
+			TypeTag: 'I',
+			Decoded: Impulse{}})
+}
 
-		case 's':
-			// In the case of a string, read until we hit a zero byte
-			byt, err := msg.Packet.ReadBytes(byte(0))
-			if errors.Is(err, io.EOF) {
-				break
-			}
-			if err != nil {
-				return err
-			}
+func (msg *Message) BeginArray() {
+	// Arguments added after BeginArray and before EndArray belong to the array
+	msg.Arguments = append(msg.Arguments, argument{TypeTag: '['})
+}
 
-			// Unread the zero byte delimiter
-			err = msg.Packet.UnreadByte()
-			if err != nil {
-				return err
-			}
-			// Remove the written zero byte from b
-			byt = trimZeroBytesRight(byt)
-
-			// Set the data and decoded data for each argument
-			msg.Arguments[i].Data = byt
-			msg.Arguments[i].Decoded = decodeArgument(byt, msg.Arguments[i].TypeTag)
-
-			// Find the bytes to skip
-			zerosToSkip := make([]byte, zeroBytesToAdd(len(byt)))
-			_, err = msg.Packet.Read(zerosToSkip)
-			if err != nil {
-				return err
-			}
+func (msg *Message) EndArray() {
+	msg.Arguments = append(msg.Arguments, argument{TypeTag: ']'})
+}
 
-		case 'b':
-			// blob is "an int32 size count, followed by that many 8-bit bytes of arbitrary binary data"
-			b := make([]byte, 4)
-			_, err := msg.Packet.Read(b)
-			if err != nil {
-				return err
-			}
-			sizeCount, ok := decodeArgument(b, 'i').(int32)
-			if !ok {
-				return fmt.Errorf("cannot parse arguments")
-			}
-			data := make([]byte, sizeCount)
-			_, err = msg.Packet.Read(data)
-			if err != nil {
-				return err
-			}
+func (msg *Message) ParseMessage() error {
+	// Parses an OSC message in the form of []byte into a message type as defined above
+	//     Padding which is short or not zero is tolerated
+	//   Errors are a *ParseError wrapping one of ErrTruncated, ErrBadPadding,
+	//     ErrUnknownTypeTag, ErrBadAddress or ErrTrailingData
+	return msg.parse(false)
+}
+
+func (msg *Message) ParseView(data []byte) error {
+	// Parses data into msg without copying it
+	//     msg.Address and each argument's Data refer into data,
+	//     so data must not change while msg is in use
+	//   Decoded is left nil on every argument to avoid allocating,
+	//     read the values with ArgFloat, ArgInt, ArgString and ArgBlob
+	//   The backing array of msg.Arguments is reused
+	if isBundle(data) {
+		return fmt.Errorf("packet is a bundle, not a message")
+	}
+	msg.Packet.Reset()
+	d := decoder{data: data, view: true}
+	return d.decodeMessage(msg)
+}
+
+func (msg *Message) ParseMessageStrict() error {
+	// Parses like ParseMessage, but rejects any packet which is not
+	//     exactly as the OSC specification describes
+	return msg.parse(true)
+}
 
-			// Set the data and decoded data for each argument
-			msg.Arguments[i].Data = data
-			msg.Arguments[i].Decoded = nil
+func (msg *Message) parse(strict bool) error {
+	// A bundle cannot be parsed as a message
+	if isBundle(msg.Packet.Bytes()) {
+		return fmt.Errorf("packet is a bundle, not a message")
+	}
+	d := decoder{data: msg.Packet.Bytes(), strict: strict}
+	return d.decodeMessage(msg)
+}
 
-			// Find the bytes to skip
-			zerosToSkip := make([]byte, zeroBytesToAdd(len(data)))
-			_, err = msg.Packet.Read(zerosToSkip)
-			if err != nil {
-				return err
-			}
+func (msg *Message) argument(i int, typeTag byte) (argument, error) {
+	// Returns the i'th argument if it has the given type tag
+	if i < 0 || i >= len(msg.Arguments) {
+		if len(msg.Arguments) == 0 {
+			return argument{}, ErrNoArguments
 		}
+		return argument{}, fmt.Errorf("no argument %d of %d: %w", i, len(msg.Arguments), ErrNoArguments)
 	}
+	arg := msg.Arguments[i]
+	if arg.TypeTag != typeTag {
+		return argument{}, &ArgumentTypeError{Index: i, Want: typeTag, Got: arg.TypeTag}
+	}
+	return arg, nil
+}
 
-	return nil
+func (msg *Message) ArgFloat(i int) (float32, error) {
+	// Returns the i'th argument, which must be a float32
+	arg, err := msg.argument(i, 'f')
+	if err != nil {
+		return 0, err
+	}
+	return byteToFloat32(arg.Data), nil
+}
+
+func (msg *Message) ArgInt(i int) (int32, error) {
+	// Returns the i'th argument, which must be an int32
+	arg, err := msg.argument(i, 'i')
+	if err != nil {
+		return 0, err
+	}
+	return byteToInt32(arg.Data), nil
+}
+
+func (msg *Message) ArgString(i int) (string, error) {
+	// Returns the i'th argument, which must be a string
+	arg, err := msg.argument(i, 's')
+	if err != nil {
+		return "", err
+	}
+	return string(arg.Data), nil
+}
+
+func (msg *Message) ArgBlob(i int) ([]byte, error) {
+	// Returns the i'th argument, which must be a blob
+	arg, err := msg.argument(i, 'b')
+	if err != nil {
+		return nil, err
+	}
+	return arg.Data, nil
 }
 
 func decodeArgument(byt []byte, typeTag byte) any {
-	// Returns the []byte decoded as a float32, int32 or string
+	// Returns the []byte decoded as the Go type matching its type tag
+	//     An argument of the wrong length decodes as nil
+	if size, ok := argumentSize[typeTag]; ok && len(byt) != size {
+		return nil
+	}
 	switch typeTag {
 	case 'i':
-		// int32 must be of length 4 bytes
-		if len(byt) != 4 {
-			return nil
-		}
 		return byteToInt32(byt)
 	case 'f':
-		// float32 must be of length 4 bytes
-		if len(byt) != 4 {
-			return nil
-		}
 		return byteToFloat32(byt)
-	case 's':
+	case 's', 'S':
 		return string(byt)
+	case 'b':
+		return byt
+	case 'h':
+		return int64(bytesToUint64(byt))
+	case 'd':
+		return bytesToFloat64(byt)
+	case 't':
+		return Timetag(bytesToUint64(byt))
+	case 'c':
+		return Char(byteToInt32(byt))
+	case 'r':
+		return color.RGBA{R: byt[0], G: byt[1], B: byt[2], A: byt[3]}
+	case 'm':
+		return MIDI{Port: byt[0], Status: byt[1], Data1: byt[2], Data2: byt[3]}
+	case 'T':
+		return true
+	case 'F':
+		return false
+	case 'I':
+		return Impulse{}
 	}
 	return nil
 }
@@ -0,0 +1,79 @@
+package osc
+
+import (
+	"testing"
+)
+
+func faderMessage() Message {
+	msg := NewMessage("/ch/01/mix/fader")
+	msg.AddFloat(0.75)
+	return msg
+}
+
+func BenchmarkMakePacket(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msg := faderMessage()
+		if err := msg.MakePacket(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAppendPacket(b *testing.B) {
+	msg := faderMessage()
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var err error
+		buf, err = msg.AppendPacket(buf[:0])
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAppendFunctions(b *testing.B) {
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = AppendString(buf[:0], "/ch/01/mix/fader")
+		buf = AppendTypeTags(buf, "f")
+		buf = AppendFloat32(buf, float32(i)/1024)
+	}
+}
+
+func BenchmarkParseMessage(b *testing.B) {
+	src := faderMessage()
+	src.MakePacket()
+	packet := src.Bytes()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var msg Message
+		msg.Packet.Write(packet)
+		if err := msg.ParseMessage(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseView(b *testing.B) {
+	// A meter reply: one blob of 4 byte little endian floats
+	src := NewMessage("/meters/1")
+	src.AddBlob(make([]byte, 4+96*4))
+	src.MakePacket()
+	packet := src.Bytes()
+	var msg Message
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := msg.ParseView(packet); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := msg.ArgBlob(0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package osc
+
+import (
+	"sync"
+)
+
+// A Handler responds to an OSC message
+type Handler interface {
+	ServeOSC(msg Message)
+}
+
+// HandlerFunc allows an ordinary function to be used as a Handler
+type HandlerFunc func(msg Message)
+
+func (f HandlerFunc) ServeOSC(msg Message) {
+	f(msg)
+}
+
+// ServeMux is an OSC method dispatcher.
+// It matches the address of each incoming message against
+// the address patterns it has registered and calls every matching handler
+type ServeMux struct {
+	mu     sync.RWMutex
+	routes []route
+	nextID uint64
+}
+
+type route struct {
+	id      uint64
+	pattern string
+	handler Handler
+}
+
+func NewServeMux() *ServeMux {
+	return &ServeMux{}
+}
+
+func (mux *ServeMux) Handle(pattern string, handler Handler) (remove func()) {
+	// Registers the handler for the given address pattern
+	//     e.g. "/ch/*/mix/fader" or "/ch/{01,02}/mix/on"
+	//   Call remove to unregister this handler alone,
+	//     leaving any others on the same pattern in place
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	mux.nextID++
+	id := mux.nextID
+	mux.routes = append(mux.routes, route{id: id, pattern: pattern, handler: handler})
+	return func() { mux.removeRoute(id) }
+}
+
+func (mux *ServeMux) HandleFunc(pattern string, handler func(msg Message)) (remove func()) {
+	return mux.Handle(pattern, HandlerFunc(handler))
+}
+
+func (mux *ServeMux) removeRoute(id uint64) {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	for i, r := range mux.routes {
+		if r.id == id {
+			mux.routes = append(mux.routes[:i:i], mux.routes[i+1:]...)
+			return
+		}
+	}
+}
+
+func (mux *ServeMux) Remove(pattern string) {
+	// Removes every handler registered for exactly the given pattern
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	routes := mux.routes[:0]
+	for _, r := range mux.routes {
+		if r.pattern != pattern {
+			routes = append(routes, r)
+		}
+	}
+	mux.routes = routes
+}
+
+func (mux *ServeMux) Handlers(address string) (handlers []Handler) {
+	// Returns the handlers whose pattern matches the address
+	//     If the address is itself a pattern, as sent by another OSC client,
+	//     it is matched against each registered pattern instead
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+	addrIsPattern := isPattern(address)
+	for _, r := range mux.routes {
+		if Match(r.pattern, address) || (addrIsPattern && Match(address, r.pattern)) {
+			handlers = append(handlers, r.handler)
+		}
+	}
+	return handlers
+}
+
+func (mux *ServeMux) matches(address string) bool {
+	// Reports whether any handler matches the address
+	//     as Handlers does, without collecting them
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+	addrIsPattern := isPattern(address)
+	for _, r := range mux.routes {
+		if Match(r.pattern, address) || (addrIsPattern && Match(address, r.pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (mux *ServeMux) ServeOSC(msg Message) {
+	// Calls every handler matching the address of msg
+	for _, h := range mux.Handlers(string(msg.Address)) {
+		h.ServeOSC(msg)
+	}
+}
+
+func (mux *ServeMux) Dispatch(p Packet) bool {
+	// Calls the matching handlers for a message,
+	//     or for each message in a bundle in order
+	//   Reports whether any handler was called
+	var messages []Message
+	switch pkt := p.(type) {
+	case *Message:
+		messages = []Message{*pkt}
+	case *Bundle:
+		messages = pkt.Messages()
+	}
+	handled := false
+	for _, msg := range messages {
+		handlers := mux.Handlers(string(msg.Address))
+		for _, h := range handlers {
+			h.ServeOSC(msg)
+		}
+		handled = handled || len(handlers) > 0
+	}
+	return handled
+}
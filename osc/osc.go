@@ -1,7 +1,10 @@
 package osc
 
 import (
+	"context"
+	"errors"
 	"net"
+	"sync"
 )
 
 func Dial(localPort int, remoteAddr string) (conn net.Conn, err error) {
@@ -20,46 +23,43 @@ func Inquire(conn net.Conn, msg Message) (reply Message, err error) {
 	// Takes a Conn and an osc Message
 	//   Sends the message to a server, and listens for a response
 	//   Returns the responding Message
-
-	// Send message
-	err = Send(conn, msg)
-	if err != nil {
-		return reply, err
-	}
-
-	// Wait for reply
-	reply, err = Listen(conn)
-	if err != nil {
-		return reply, err
-	}
-
-	return reply, nil
+	//   Gives up after DefaultInquireTimeout, see InquireContext for more control
+	return InquireContext(context.Background(), conn, msg, InquireOptions{})
 }
 
 func Send(conn net.Conn, msg Message) error {
 	// Send an OSC message of type Message to the Conn connection
+	return SendPacket(conn, &msg)
+}
 
+func SendBundle(conn net.Conn, b Bundle) error {
+	// Send an OSC bundle to the Conn connection
+	//     All messages in the bundle arrive in a single packet
+	return SendPacket(conn, &b)
+}
+
+func SendPacket(conn net.Conn, p Packet) error {
 	// Make the packet from the components if it doesn't already exist
-	if msg.Packet.Len() == 0 {
-		err := msg.MakePacket()
+	if len(p.Bytes()) == 0 {
+		err := p.MakePacket()
 		if err != nil {
 			return err
 		}
 	}
 
 	// Write the bytes to the connection
-	_, err := conn.Write(msg.Packet.Bytes())
+	_, err := conn.Write(p.Bytes())
 	return err
 }
 
 func Listen(conn net.Conn) (msg Message, err error) {
 	// Act as a server and listen for an incoming OSC message
-	// Make a []byte of length 512 and read into it
-	byt := make([]byte, 512)
-	_, err = conn.Read(byt)
+	// Read one datagram, sized to the bytes actually received
+	byt, release, err := readPacket(conn)
 	if err != nil {
 		return msg, err
 	}
+	defer release()
 
 	// Write bytes to packet
 	msg.Packet.Write(byt)
@@ -71,3 +71,60 @@ func Listen(conn net.Conn) (msg Message, err error) {
 	// Return msg
 	return msg, err
 }
+
+func ListenPacket(conn net.Conn) (Packet, error) {
+	// Listen for an incoming OSC packet which may be a message or a bundle
+	//     The returned Packet is either a *Message or a *Bundle
+	byt, release, err := readPacket(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return ParsePacket(byt)
+}
+
+func ListenView(conn net.Conn, buf []byte, msg *Message) error {
+	// Reads one datagram into buf and parses it into msg with ParseView
+	//     msg refers into buf until buf is next read into,
+	//     so a loop can reuse both without allocating
+	//   buf should be MaxPacketSize long to receive any datagram
+	n, err := conn.Read(buf)
+	if err == nil && n == len(buf) {
+		err = ErrPacketTruncated
+	}
+	if err != nil {
+		return err
+	}
+	return msg.ParseView(buf[:n])
+}
+
+// MaxPacketSize is the largest datagram that can be received.
+// It is larger than any UDP payload, so a read which fills it was truncated
+const MaxPacketSize = 65536
+
+// ErrPacketTruncated is returned when an incoming packet did not fit in the read buffer
+var ErrPacketTruncated = errors.New("packet truncated")
+
+var packetPool = sync.Pool{
+	New: func() any {
+		byt := make([]byte, MaxPacketSize)
+		return &byt
+	},
+}
+
+func readPacket(conn net.Conn) (byt []byte, release func(), err error) {
+	// Reads a single datagram into a pooled buffer
+	//     Returns only the bytes received, which remain valid until release is called
+	buf := packetPool.Get().(*[]byte)
+	release = func() { packetPool.Put(buf) }
+	n, err := conn.Read(*buf)
+	if err == nil && n == len(*buf) {
+		err = ErrPacketTruncated
+	}
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return (*buf)[:n], release, nil
+}
@@ -0,0 +1,258 @@
+package osc
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimetag(t *testing.T) {
+	tests := []struct {
+		name     string
+		time     time.Time
+		seconds  uint32
+		fraction uint32
+	}{
+		{"unix epoch", time.Unix(0, 0), 2208988800, 0},
+		{"half a second", time.Unix(1, 500000000), 2208988801, 0x80000000},
+		{"quarter of a second", time.Unix(0, 250000000), 2208988800, 0x40000000},
+		{"2024", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), 3923553600, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := NewTimetag(tc.time)
+			if tt.Seconds() != tc.seconds || tt.Fraction() != tc.fraction {
+				t.Errorf("NewTimetag = %d.%08x, want %d.%08x", tt.Seconds(), tt.Fraction(), tc.seconds, tc.fraction)
+			}
+			if got := tt.Time(); !got.Equal(tc.time) {
+				t.Errorf("Time = %v, want %v", got, tc.time)
+			}
+		})
+	}
+	if since := time.Since(Immediately.Time()); since < 0 || since > time.Second {
+		t.Errorf("Immediately.Time() is %v from now, want now", since)
+	}
+}
+
+func TestBundleEncoding(t *testing.T) {
+	on := NewMessage("/ch/01/mix/on")
+	on.AddInt(1)
+	inner := NewBundle(Timetag(0x0000000200000000))
+	inner.AddMessage(on)
+	tests := []struct {
+		name     string
+		bundle   func() Bundle
+		packet   string // hex, spaces ignored
+		messages []string
+	}{
+		{
+			name:   "empty",
+			bundle: func() Bundle { return NewBundle(Immediately) },
+			packet: "2362756e 646c6500 00000000 00000001",
+		},
+		{
+			name: "one message",
+			bundle: func() Bundle {
+				b := NewBundle(Immediately)
+				b.AddMessage(on)
+				return b
+			},
+			packet: "2362756e 646c6500 00000000 00000001" +
+				"00000018 2f63682f 30312f6d 69782f6f 6e000000 2c690000 00000001",
+			messages: []string{"/ch/01/mix/on ,i 1"},
+		},
+		{
+			name: "nested",
+			bundle: func() Bundle {
+				b := NewBundle(Timetag(0x0000000100000000))
+				b.AddMessage(NewMessage("/xremote"))
+				b.AddBundle(inner)
+				return b
+			},
+			packet: "2362756e 646c6500 00000001 00000000" +
+				"0000000c 2f787265 6d6f7465 00000000" +
+				"0000002c 2362756e 646c6500 00000002 00000000" +
+				"00000018 2f63682f 30312f6d 69782f6f 6e000000 2c690000 00000001",
+			messages: []string{"/xremote", "/ch/01/mix/on ,i 1"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := tc.bundle()
+			want := goldenBytes(t, tc.packet)
+			if err := b.MakePacket(); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b.Bytes(), want) {
+				t.Errorf("MakePacket = %x, want %x", b.Bytes(), want)
+			}
+
+			p, err := ParsePacketStrict(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, ok := p.(*Bundle)
+			if !ok {
+				t.Fatalf("parsed %T, want *Bundle", p)
+			}
+			if parsed.Timetag != b.Timetag {
+				t.Errorf("Timetag = %x, want %x", parsed.Timetag, b.Timetag)
+			}
+			messages := parsed.Messages()
+			if len(messages) != len(tc.messages) {
+				t.Fatalf("%d messages, want %d", len(messages), len(tc.messages))
+			}
+			for i, msg := range messages {
+				if msg.Format() != tc.messages[i] {
+					t.Errorf("message %d = %s, want %s", i, msg.Format(), tc.messages[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMalformedBundles(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet string
+		strict bool
+		want   error
+	}{
+		{"no timetag", "2362756e 646c6500 00000000", false, ErrTruncated},
+		{"element overruns packet", "2362756e 646c6500 00000000 00000001 00000020 2f610000", false, ErrTruncated},
+		{"negative element size", "2362756e 646c6500 00000000 00000001 ffffffff 2f610000", false, ErrTruncated},
+		{"element size cut short", "2362756e 646c6500 00000000 00000001 0000", false, ErrTruncated},
+		{"unpadded element size", "2362756e 646c6500 00000000 00000001 00000003 2f6100", true, ErrBadPadding},
+		{"malformed element", "2362756e 646c6500 00000000 00000001 00000008 2f610000 2c780000", false, ErrUnknownTypeTag},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			packet := goldenBytes(t, tc.packet)
+			var err error
+			if tc.strict {
+				_, err = ParsePacketStrict(packet)
+			} else {
+				_, err = ParsePacket(packet)
+			}
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestArgumentTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		add      func(msg *Message)
+		packet   string // hex after the address "/a", spaces ignored
+		typeTags string
+		decoded  []any
+	}{
+		{"blob", func(m *Message) { m.AddBlob([]byte{1, 2, 3}) },
+			"2c620000 00000003 01020300", "b", []any{[]byte{1, 2, 3}}},
+		{"empty blob", func(m *Message) { m.AddBlob([]byte{}) },
+			"2c620000 00000000", "b", []any{[]byte{}}},
+		{"int64", func(m *Message) { m.AddInt64(-2) },
+			"2c680000 ffffffff fffffffe", "h", []any{int64(-2)}},
+		{"double", func(m *Message) { m.AddDouble(1.5) },
+			"2c640000 3ff80000 00000000", "d", []any{1.5}},
+		{"timetag", func(m *Message) { m.AddTimetag(Timetag(0x0000000100000002)) },
+			"2c740000 00000001 00000002", "t", []any{Timetag(0x0000000100000002)}},
+		{"symbol", func(m *Message) { m.AddSymbol("hi") },
+			"2c530000 68690000", "S", []any{"hi"}},
+		{"char", func(m *Message) { m.AddChar('A') },
+			"2c630000 00000041", "c", []any{Char('A')}},
+		{"rgba", func(m *Message) { m.AddRGBA(color.RGBA{R: 1, G: 2, B: 3, A: 4}) },
+			"2c720000 01020304", "r", []any{color.RGBA{R: 1, G: 2, B: 3, A: 4}}},
+		{"midi", func(m *Message) { m.AddMIDI(MIDI{Status: 0x90, Data1: 60, Data2: 100}) },
+			"2c6d0000 00903c64", "m", []any{MIDI{Status: 0x90, Data1: 60, Data2: 100}}},
+		{"no payload", func(m *Message) {
+			m.AddBool(true)
+			m.AddBool(false)
+			m.AddNil()
+			m.AddImpulse()
+		}, "2c54464e 49000000", "TFNI", []any{true, false, nil, Impulse{}}},
+		{"array", func(m *Message) {
+			m.AddInt(7)
+			m.BeginArray()
+			m.AddInt(1)
+			m.AddString("x")
+			m.EndArray()
+		}, "2c695b69 735d0000 00000007 00000001 78000000", "i[is]", []any{int32(7), nil, int32(1), "x", nil}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := NewMessage("/a")
+			tc.add(&msg)
+			want := goldenBytes(t, "2f610000"+tc.packet)
+			if err := msg.MakePacket(); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(msg.Bytes(), want) {
+				t.Errorf("MakePacket = %x, want %x", msg.Bytes(), want)
+			}
+
+			var parsed Message
+			parsed.Packet.Write(want)
+			if err := parsed.ParseMessageStrict(); err != nil {
+				t.Fatal(err)
+			}
+			var typeTags []byte
+			var decoded []any
+			for _, arg := range parsed.Arguments {
+				typeTags = append(typeTags, arg.TypeTag)
+				decoded = append(decoded, arg.Decoded)
+			}
+			if string(typeTags) != tc.typeTags {
+				t.Errorf("type tags %q, want %q", typeTags, tc.typeTags)
+			}
+			if !reflect.DeepEqual(decoded, tc.decoded) {
+				t.Errorf("decoded %#v, want %#v", decoded, tc.decoded)
+			}
+		})
+	}
+}
+
+func TestMalformedArguments(t *testing.T) {
+	tests := []struct {
+		name    string
+		packet  string // hex after the address "/a"
+		strict  bool
+		typeTag byte
+		want    error
+	}{
+		{"unknown type tag", "2c7a0000 00000001", false, 'z', ErrUnknownTypeTag},
+		{"unknown after known", "2c697a00 00000001 00000001", false, 'z', ErrUnknownTypeTag},
+		{"int64 cut short", "2c680000 00000001", false, 'h', ErrTruncated},
+		{"double cut short", "2c640000 3ff80000", false, 'd', ErrTruncated},
+		{"timetag cut short", "2c740000 00000001", false, 't', ErrTruncated},
+		{"midi cut short", "2c6d0000 0090", false, 'm', ErrTruncated},
+		{"blob overruns packet", "2c620000 00000010 01020304", false, 'b', ErrTruncated},
+		{"blob size cut short", "2c620000 0000", false, 'b', ErrTruncated},
+		// Only strict parsing refuses a final string missing its terminator
+		{"unterminated symbol", "2c530000 68696869", true, 'S', ErrTruncated},
+		{"symbol padding", "2c530000 68690001", true, 'S', ErrBadPadding},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var msg Message
+			msg.Packet.Write(goldenBytes(t, "2f610000"+tc.packet))
+			parse := msg.ParseMessage
+			if tc.strict {
+				parse = msg.ParseMessageStrict
+			}
+			err := parse()
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || parseErr.TypeTag != tc.typeTag {
+				t.Errorf("err = %#v, want a *ParseError for '%c'", err, tc.typeTag)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package osc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// LINKTYPE_RAW, each pcap record is a bare IP packet
+const pcapLinkTypeRaw = 101
+
+// The IPv4 total length is 16 bits and covers the 28 bytes of IPv4
+// and UDP headers, so this is the largest payload a record can hold.
+// The snapshot length is the conventional 65535, which fits every record
+const (
+	pcapSnapLen       = 65535
+	pcapMaxPayload    = pcapSnapLen - udpFrameHeaderLen
+	udpFrameHeaderLen = 28
+)
+
+func WritePcap(w io.Writer, records []Record) error {
+	// Writes the session as a pcap capture of UDP over IPv4
+	//     so it can be opened with Wireshark or tcpdump
+	//   Addresses which are not IPv4 are written as 0.0.0.0
+	//   A packet too large for a UDP datagram is an error,
+	//     and nothing is written
+	for i, rec := range records {
+		if len(rec.Packet) > pcapMaxPayload {
+			return fmt.Errorf("record %d: packet of %d bytes is larger than a UDP datagram", i, len(rec.Packet))
+		}
+	}
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:], 0xa1b2c3d4) // microsecond timestamps
+	binary.LittleEndian.PutUint16(header[4:], 2)          // version 2.4
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], pcapSnapLen)
+	binary.LittleEndian.PutUint32(header[20:], pcapLinkTypeRaw)
+	_, err := w.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range records {
+		src, dst := rec.Local, rec.Remote
+		if rec.Direction == DirRecv {
+			src, dst = dst, src
+		}
+		frame := udpFrame(src, dst, rec.Packet)
+
+		recHeader := make([]byte, 16)
+		binary.LittleEndian.PutUint32(recHeader[0:], uint32(rec.Time.Unix()))
+		binary.LittleEndian.PutUint32(recHeader[4:], uint32(rec.Time.Nanosecond()/1000))
+		binary.LittleEndian.PutUint32(recHeader[8:], uint32(len(frame)))
+		binary.LittleEndian.PutUint32(recHeader[12:], uint32(len(frame)))
+		_, err = w.Write(recHeader)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(frame)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func udpFrame(src, dst string, payload []byte) []byte {
+	// Builds an IPv4 header and a UDP header around the payload
+	srcIP, srcPort := splitIPv4(src)
+	dstIP, dstPort := splitIPv4(dst)
+	//   The payload must be at most pcapMaxPayload long
+	frame := make([]byte, udpFrameHeaderLen, udpFrameHeaderLen+len(payload))
+
+	// IPv4 header
+	frame[0] = 0x45 // version 4, 20 byte header
+	binary.BigEndian.PutUint16(frame[2:], uint16(len(frame)+len(payload)))
+	binary.BigEndian.PutUint16(frame[6:], 0x4000) // don't fragment
+	frame[8] = 64                                 // TTL
+	frame[9] = 17                                 // UDP
+	copy(frame[12:16], srcIP)
+	copy(frame[16:20], dstIP)
+	binary.BigEndian.PutUint16(frame[10:], ipChecksum(frame[:20]))
+
+	// UDP header, a zero checksum means none was computed
+	binary.BigEndian.PutUint16(frame[20:], srcPort)
+	binary.BigEndian.PutUint16(frame[22:], dstPort)
+	binary.BigEndian.PutUint16(frame[24:], uint16(8+len(payload)))
+
+	return append(frame, payload...)
+}
+
+func splitIPv4(addr string) (net.IP, uint16) {
+	ip := net.IPv4zero.To4()
+	host, portS, err := net.SplitHostPort(addr)
+	if err != nil {
+		return ip, 0
+	}
+	if parsed := net.ParseIP(host).To4(); parsed != nil {
+		ip = parsed
+	}
+	port, _ := strconv.ParseUint(portS, 10, 16)
+	return ip, uint16(port)
+}
+
+func ipChecksum(header []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(header); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(header[i:]))
+	}
+	for sum > 0xffff {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,168 @@
+package osc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Direction tells whether a recorded packet was sent or received
+type Direction string
+
+const (
+	DirSend Direction = "send"
+	DirRecv Direction = "recv"
+)
+
+// Record is one packet of a recorded session, stored as a line of JSON
+type Record struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"dir"`
+	Local     string    `json:"local,omitempty"`  // our address, "ip:port"
+	Remote    string    `json:"remote,omitempty"` // the other side's address, "ip:port"
+	Text      string    `json:"text,omitempty"`   // the packet in the text format, for reading
+	Packet    []byte    `json:"packet"`
+}
+
+// Recorder writes every packet it is given to w as JSON lines
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+func (r *Recorder) Record(dir Direction, local, remote net.Addr, packet []byte) error {
+	// Writes one packet to the session, stamped with the current time
+	rec := Record{
+		Time:      time.Now(),
+		Direction: dir,
+		Text:      packetText(packet),
+		Packet:    append([]byte(nil), packet...),
+	}
+	if local != nil {
+		rec.Local = local.String()
+	}
+	if remote != nil {
+		rec.Remote = remote.String()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(rec)
+}
+
+func packetText(packet []byte) string {
+	// Describes the packet in the text format
+	//     Messages of a bundle are separated by semicolons
+	p, err := ParsePacket(packet)
+	if err != nil {
+		return ""
+	}
+	switch pkt := p.(type) {
+	case *Message:
+		return pkt.Format()
+	case *Bundle:
+		var lines []string
+		for _, msg := range pkt.Messages() {
+			lines = append(lines, msg.Format())
+		}
+		return "#bundle " + strings.Join(lines, "; ")
+	}
+	return ""
+}
+
+// recordConn is a net.Conn which records every packet read or written
+type recordConn struct {
+	net.Conn
+	rec *Recorder
+}
+
+func RecordConn(conn net.Conn, rec *Recorder) net.Conn {
+	// Wraps conn so every packet passing through it is recorded
+	//     Recording errors are ignored so they never disturb the connection
+	return &recordConn{Conn: conn, rec: rec}
+}
+
+func (rc *recordConn) Write(byt []byte) (int, error) {
+	n, err := rc.Conn.Write(byt)
+	if err == nil {
+		rc.rec.Record(DirSend, rc.LocalAddr(), rc.RemoteAddr(), byt)
+	}
+	return n, err
+}
+
+func (rc *recordConn) Read(byt []byte) (int, error) {
+	n, err := rc.Conn.Read(byt)
+	if err == nil {
+		rc.rec.Record(DirRecv, rc.LocalAddr(), rc.RemoteAddr(), byt[:n])
+	}
+	return n, err
+}
+
+func ReadSession(r io.Reader) (records []Record, err error) {
+	// Reads a session written by a Recorder
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*MaxPacketSize)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var rec Record
+		err = json.Unmarshal(scanner.Bytes(), &rec)
+		if err != nil {
+			return records, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// ReplayOptions controls how Replay re-sends a session
+type ReplayOptions struct {
+	Speed     float64   // 1 keeps the recorded timing, 2 is twice as fast, 0 sends without waiting
+	Direction Direction // which packets to send, DirSend if empty
+}
+
+func Replay(ctx context.Context, conn net.Conn, records []Record, opts ReplayOptions) error {
+	// Sends the recorded packets of the chosen direction to conn
+	//     keeping the gaps between them as recorded, scaled by opts.Speed
+	dir := opts.Direction
+	if dir == "" {
+		dir = DirSend
+	}
+	var first time.Time
+	start := time.Now()
+	for _, rec := range records {
+		if rec.Direction != dir {
+			continue
+		}
+		if first.IsZero() {
+			first = rec.Time
+		}
+		if opts.Speed > 0 {
+			due := start.Add(time.Duration(float64(rec.Time.Sub(first)) / opts.Speed))
+			timer := time.NewTimer(time.Until(due))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		_, err := conn.Write(rec.Packet)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package osc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// captureConn keeps every packet written to it, with the time it arrived
+type captureConn struct {
+	net.Conn
+	mu      sync.Mutex
+	packets [][]byte
+	times   []time.Time
+}
+
+func (cc *captureConn) Write(b []byte) (int, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.packets = append(cc.packets, append([]byte(nil), b...))
+	cc.times = append(cc.times, time.Now())
+	return len(b), nil
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	console, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer console.Close()
+	udp, err := net.Dial("udp", console.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+
+	// Record a query and its reply
+	var session bytes.Buffer
+	conn := RecordConn(udp, NewRecorder(&session))
+	query := NewMessage("/ch/01/mix/fader")
+	if err := Send(conn, query); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, MaxPacketSize)
+	console.SetReadDeadline(time.Now().Add(time.Second))
+	_, from, err := console.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := NewMessage("/ch/01/mix/fader")
+	reply.AddFloat(0.75)
+	reply.MakePacket()
+	if _, err := console.WriteTo(reply.Bytes(), from); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read it back
+	records, err := ReadSession(&session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("read %d records, want 2", len(records))
+	}
+	want := []struct {
+		dir  Direction
+		text string
+	}{
+		{DirSend, "/ch/01/mix/fader"},
+		{DirRecv, "/ch/01/mix/fader ,f 0.75"},
+	}
+	for i, rec := range records {
+		if rec.Direction != want[i].dir || rec.Text != want[i].text {
+			t.Errorf("record %d = %s %q, want %s %q", i, rec.Direction, rec.Text, want[i].dir, want[i].text)
+		}
+		if rec.Local != udp.LocalAddr().String() || rec.Remote != console.LocalAddr().String() {
+			t.Errorf("record %d between %s and %s, want %s and %s", i, rec.Local, rec.Remote, udp.LocalAddr(), console.LocalAddr())
+		}
+		if rec.Time.IsZero() {
+			t.Errorf("record %d has no time", i)
+		}
+	}
+	if want := mustPacket(t, NewMessage("/ch/01/mix/fader")); !bytes.Equal(records[0].Packet, want) {
+		t.Errorf("recorded query % x, want % x", records[0].Packet, want)
+	}
+	if !bytes.Equal(records[1].Packet, reply.Bytes()) {
+		t.Errorf("recorded reply % x, want % x", records[1].Packet, reply.Bytes())
+	}
+
+	// Replay the sent packets
+	var replayed captureConn
+	if err := Replay(context.Background(), &replayed, records, ReplayOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed.packets) != 1 || !bytes.Equal(replayed.packets[0], records[0].Packet) {
+		t.Errorf("replayed %d packets, want the recorded query", len(replayed.packets))
+	}
+}
+
+func mustPacket(t *testing.T, msg Message) []byte {
+	t.Helper()
+	if err := msg.MakePacket(); err != nil {
+		t.Fatal(err)
+	}
+	return msg.Bytes()
+}
+
+func TestReadSessionErrors(t *testing.T) {
+	session := `{"time":"2024-01-01T00:00:00Z","dir":"send","packet":"L2luZm8AAAAA"}
+
+not json
+`
+	records, err := ReadSession(strings.NewReader(session))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("ReadSession error = %v, want one on line 3", err)
+	}
+	if len(records) != 1 || string(trimZeroBytesRight(records[0].Packet)) != "/info" {
+		t.Errorf("records before the error = %v, want the /info packet", records)
+	}
+}
+
+func TestReplayTiming(t *testing.T) {
+	start := time.Now()
+	packet := mustPacket(t, NewMessage("/xremote"))
+	records := []Record{
+		{Time: start, Direction: DirSend, Packet: packet},
+		{Time: start.Add(10 * time.Millisecond), Direction: DirRecv, Packet: packet},
+		{Time: start.Add(100 * time.Millisecond), Direction: DirSend, Packet: packet},
+		{Time: start.Add(200 * time.Millisecond), Direction: DirSend, Packet: packet},
+	}
+	tests := []struct {
+		name  string
+		opts  ReplayOptions
+		count int
+		gap   time.Duration // expected time from the first packet to the last
+	}{
+		{"recorded timing", ReplayOptions{Speed: 1}, 3, 200 * time.Millisecond},
+		{"twice as fast", ReplayOptions{Speed: 2}, 3, 100 * time.Millisecond},
+		{"no waiting", ReplayOptions{}, 3, 0},
+		{"received packets", ReplayOptions{Direction: DirRecv}, 1, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var conn captureConn
+			if err := Replay(context.Background(), &conn, records, tc.opts); err != nil {
+				t.Fatal(err)
+			}
+			if len(conn.packets) != tc.count {
+				t.Fatalf("replayed %d packets, want %d", len(conn.packets), tc.count)
+			}
+			gap := conn.times[len(conn.times)-1].Sub(conn.times[0])
+			if gap < tc.gap || gap > tc.gap+50*time.Millisecond {
+				t.Errorf("replayed over %v, want %v", gap, tc.gap)
+			}
+		})
+	}
+
+	// Cancelling stops the replay between packets
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var conn captureConn
+	err := Replay(ctx, &conn, records, ReplayOptions{Speed: 1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Replay = %v, want context.DeadlineExceeded", err)
+	}
+	if len(conn.packets) != 1 {
+		t.Errorf("replayed %d packets before the deadline, want 1", len(conn.packets))
+	}
+}
+
+func TestWritePcap(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 250000000, time.UTC)
+	query := mustPacket(t, NewMessage("/info"))
+	reply := NewMessage("/info")
+	reply.AddString("V2.05")
+	records := []Record{
+		{Time: at, Direction: DirSend, Local: "192.168.1.10:50000", Remote: "192.168.1.20:10023", Packet: query},
+		{Time: at.Add(time.Millisecond), Direction: DirRecv, Local: "192.168.1.10:50000", Remote: "192.168.1.20:10023", Packet: mustPacket(t, reply)},
+	}
+	var capture bytes.Buffer
+	if err := WritePcap(&capture, records); err != nil {
+		t.Fatal(err)
+	}
+	data := capture.Bytes()
+
+	// Global header
+	le := binary.LittleEndian
+	if len(data) < 24 {
+		t.Fatalf("capture is %d bytes, too short for its header", len(data))
+	}
+	if magic := le.Uint32(data); magic != 0xa1b2c3d4 {
+		t.Errorf("magic 0x%08x", magic)
+	}
+	if major, minor := le.Uint16(data[4:]), le.Uint16(data[6:]); major != 2 || minor != 4 {
+		t.Errorf("version %d.%d, want 2.4", major, minor)
+	}
+	if snaplen := le.Uint32(data[16:]); snaplen != 65535 {
+		t.Errorf("snapshot length %d", snaplen)
+	}
+	if linkType := le.Uint32(data[20:]); linkType != pcapLinkTypeRaw {
+		t.Errorf("link type %d, want %d", linkType, pcapLinkTypeRaw)
+	}
+	data = data[24:]
+
+	be := binary.BigEndian
+	wants := []struct {
+		src, dst         string
+		srcPort, dstPort uint16
+	}{
+		{"192.168.1.10", "192.168.1.20", 50000, 10023},
+		{"192.168.1.20", "192.168.1.10", 10023, 50000},
+	}
+	for i, rec := range records {
+		if len(data) < 16 {
+			t.Fatalf("record %d: %d bytes left, too short for a record header", i, len(data))
+		}
+		seconds, micros := le.Uint32(data), le.Uint32(data[4:])
+		if got := time.Unix(int64(seconds), int64(micros)*1000); !got.Equal(rec.Time) {
+			t.Errorf("record %d: time %v, want %v", i, got.UTC(), rec.Time)
+		}
+		captured, length := le.Uint32(data[8:]), le.Uint32(data[12:])
+		if captured != length || int(captured) != 28+len(rec.Packet) {
+			t.Errorf("record %d: lengths %d and %d, want %d", i, captured, length, 28+len(rec.Packet))
+		}
+		data = data[16:]
+		if len(data) < int(captured) {
+			t.Fatalf("record %d: %d bytes left, want %d", i, len(data), captured)
+		}
+		frame := data[:captured]
+		data = data[captured:]
+
+		// IPv4 header
+		if frame[0] != 0x45 || frame[9] != 17 {
+			t.Errorf("record %d: version byte 0x%02x, protocol %d", i, frame[0], frame[9])
+		}
+		if total := be.Uint16(frame[2:]); int(total) != len(frame) {
+			t.Errorf("record %d: IP length %d, want %d", i, total, len(frame))
+		}
+		if sum := ipChecksum(frame[:20]); sum != 0 {
+			t.Errorf("record %d: IP header checksum does not verify", i)
+		}
+		if src, dst := net.IP(frame[12:16]).String(), net.IP(frame[16:20]).String(); src != wants[i].src || dst != wants[i].dst {
+			t.Errorf("record %d: from %s to %s, want %s to %s", i, src, dst, wants[i].src, wants[i].dst)
+		}
+
+		// UDP header and payload
+		if src, dst := be.Uint16(frame[20:]), be.Uint16(frame[22:]); src != wants[i].srcPort || dst != wants[i].dstPort {
+			t.Errorf("record %d: ports %d to %d, want %d to %d", i, src, dst, wants[i].srcPort, wants[i].dstPort)
+		}
+		if udpLen := be.Uint16(frame[24:]); int(udpLen) != 8+len(rec.Packet) {
+			t.Errorf("record %d: UDP length %d, want %d", i, udpLen, 8+len(rec.Packet))
+		}
+		if !bytes.Equal(frame[28:], rec.Packet) {
+			t.Errorf("record %d: payload % x, want % x", i, frame[28:], rec.Packet)
+		}
+	}
+	if len(data) != 0 {
+		t.Errorf("%d bytes after the last record", len(data))
+	}
+
+	// The largest payload fits its length fields, one more does not
+	largest := Record{Time: at, Direction: DirSend, Packet: make([]byte, pcapMaxPayload)}
+	capture.Reset()
+	if err := WritePcap(&capture, []Record{largest}); err != nil {
+		t.Fatal(err)
+	}
+	frame := capture.Bytes()[24+16:]
+	if total := be.Uint16(frame[2:]); int(total) != len(frame) || len(frame) != 65535 {
+		t.Errorf("largest record: IP length %d, frame %d bytes, want 65535", total, len(frame))
+	}
+	if udpLen := be.Uint16(frame[24:]); int(udpLen) != 8+pcapMaxPayload {
+		t.Errorf("largest record: UDP length %d, want %d", udpLen, 8+pcapMaxPayload)
+	}
+	oversize := Record{Time: at, Direction: DirSend, Packet: make([]byte, pcapMaxPayload+1)}
+	capture.Reset()
+	if err := WritePcap(&capture, []Record{records[0], oversize}); err == nil {
+		t.Error("wrote a packet too large for a UDP datagram")
+	}
+	if capture.Len() != 0 {
+		t.Errorf("wrote %d bytes of a capture which failed", capture.Len())
+	}
+}
@@ -0,0 +1,239 @@
+package osc
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+// A PacketHandler responds to an OSC packet received by a Server
+type PacketHandler interface {
+	ServePacket(p Packet, from Peer)
+}
+
+// PacketHandlerFunc allows an ordinary function to be used as a PacketHandler
+type PacketHandlerFunc func(p Packet, from Peer)
+
+func (f PacketHandlerFunc) ServePacket(p Packet, from Peer) {
+	f(p, from)
+}
+
+func (mux *ServeMux) ServePacket(p Packet, from Peer) {
+	// Allows a ServeMux to be used as the handler of a Server
+	mux.Dispatch(p)
+}
+
+// Peer is the sender of a packet received by a Server
+type Peer struct {
+	Addr   net.Addr
+	conn   net.PacketConn
+	stream *StreamConn // set instead of conn when the packet arrived over a stream
+}
+
+func (peer Peer) Send(msg Message) error {
+	// Send an OSC message back to the peer
+	return peer.SendPacket(&msg)
+}
+
+func (peer Peer) SendPacket(p Packet) error {
+	// Send an OSC message or bundle back to the peer
+	if len(p.Bytes()) == 0 {
+		err := p.MakePacket()
+		if err != nil {
+			return err
+		}
+	}
+	if peer.stream != nil {
+		_, err := peer.stream.Write(p.Bytes())
+		return err
+	}
+	_, err := peer.conn.WriteTo(p.Bytes(), peer.Addr)
+	return err
+}
+
+// Server listens on a UDP port and hands every packet it receives,
+// from any client, to its Handler
+type Server struct {
+	Addr     string // UDP address to listen on, e.g. ":10023"
+	Handler  PacketHandler
+	ErrorLog func(err error, from net.Addr) // called for packets which cannot be parsed, may be nil
+
+	mu        sync.Mutex
+	conn      net.PacketConn
+	listeners []net.Listener
+	streams   map[net.Conn]bool
+	closed    bool
+}
+
+// ErrServerClosed is returned by Serve after a call to Close
+var ErrServerClosed = errors.New("server closed")
+
+func (s *Server) ListenAndServe() error {
+	// Listen on s.Addr and serve until the server is closed
+	conn, err := net.ListenPacket("udp", s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(conn)
+}
+
+func (s *Server) Serve(conn net.PacketConn) error {
+	// Read packets from conn and pass each to s.Handler in the order received
+	//     Serve takes ownership of conn and closes it on return
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return ErrServerClosed
+	}
+	s.conn = conn
+	s.mu.Unlock()
+	defer conn.Close()
+
+	byt := make([]byte, MaxPacketSize)
+	for {
+		n, addr, err := conn.ReadFrom(byt)
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if n == len(byt) {
+			if s.ErrorLog != nil {
+				s.ErrorLog(ErrPacketTruncated, addr)
+			}
+			continue
+		}
+		p, err := ParsePacket(byt[:n])
+		if err != nil {
+			if s.ErrorLog != nil {
+				s.ErrorLog(err, addr)
+			}
+			continue
+		}
+		if s.Handler != nil {
+			s.Handler.ServePacket(p, Peer{Addr: addr, conn: conn})
+		}
+	}
+}
+
+func (s *Server) ListenAndServeTCP(addr string, framing Framing) error {
+	// Listen for TCP connections on addr and serve until the server is closed
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.ServeStream(l, framing)
+}
+
+func (s *Server) ServeStream(l net.Listener, framing Framing) error {
+	// Accept stream connections from l and serve the packets from each
+	//     Packets from one connection reach s.Handler in the order sent,
+	//     and replies through the Peer go back over the same connection
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners = append(s.listeners, l)
+	s.mu.Unlock()
+	defer l.Close()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if !s.trackStream(conn, true) {
+			conn.Close()
+			return ErrServerClosed
+		}
+		go s.serveStreamConn(NewStreamConn(conn, framing))
+	}
+}
+
+func (s *Server) serveStreamConn(sc *StreamConn) {
+	defer s.trackStream(sc.Conn, false)
+	defer sc.Close()
+	byt := make([]byte, MaxPacketSize)
+	for {
+		n, err := sc.Read(byt)
+		if errors.Is(err, ErrPacketTruncated) {
+			if s.ErrorLog != nil {
+				s.ErrorLog(err, sc.RemoteAddr())
+			}
+			continue
+		}
+		if err != nil {
+			return
+		}
+		p, err := ParsePacket(byt[:n])
+		if err != nil {
+			if s.ErrorLog != nil {
+				s.ErrorLog(err, sc.RemoteAddr())
+			}
+			continue
+		}
+		if s.Handler != nil {
+			s.Handler.ServePacket(p, Peer{Addr: sc.RemoteAddr(), stream: sc})
+		}
+	}
+}
+
+func (s *Server) trackStream(conn net.Conn, add bool) bool {
+	// Keeps the set of open stream connections so Close can end them
+	//     Reports false if the server is already closed
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !add {
+		delete(s.streams, conn)
+		return true
+	}
+	if s.closed {
+		return false
+	}
+	if s.streams == nil {
+		s.streams = make(map[net.Conn]bool)
+	}
+	s.streams[conn] = true
+	return true
+}
+
+func (s *Server) LocalAddr() net.Addr {
+	// Returns the address the server is listening on
+	//     or nil if it is not yet serving
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.LocalAddr()
+}
+
+func (s *Server) Close() error {
+	// Stop the server, causing Serve and ServeStream to return ErrServerClosed
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for _, l := range s.listeners {
+		l.Close()
+	}
+	for conn := range s.streams {
+		conn.Close()
+	}
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
@@ -0,0 +1,119 @@
+package osc
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServerLoopback(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 4)
+	badPackets := make(chan error, 1)
+	s := &Server{
+		Handler: PacketHandlerFunc(func(p Packet, from Peer) {
+			msg, ok := p.(*Message)
+			if !ok {
+				return
+			}
+			received <- string(msg.Address)
+			// Reply to the sender
+			reply := NewMessage("/pong")
+			reply.AddString(string(msg.Address))
+			if err := from.Send(reply); err != nil {
+				t.Error(err)
+			}
+		}),
+		ErrorLog: func(err error, from net.Addr) {
+			badPackets <- err
+		},
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(conn)
+	}()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// Every packet is dispatched and answered
+	for _, address := range []string{"/ping", "/ch/01/mix/fader"} {
+		msg := NewMessage(address)
+		if err := msg.MakePacket(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Write(msg.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-received:
+			if got != address {
+				t.Errorf("handler got %s, want %s", got, address)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no packet reached the handler for %s", address)
+		}
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		buf := make([]byte, MaxPacketSize)
+		n, err := client.Read(buf)
+		if err != nil {
+			t.Fatalf("no reply for %s: %v", address, err)
+		}
+		var reply Message
+		if err := reply.ParseView(buf[:n]); err != nil {
+			t.Fatal(err)
+		}
+		if s, _ := reply.ArgString(0); string(reply.Address) != "/pong" || s != address {
+			t.Errorf("reply %s %q, want /pong %q", reply.Address, s, address)
+		}
+	}
+
+	// Packets which cannot be parsed go to ErrorLog, not the handler
+	if _, err := client.Write([]byte("/a\x00\x00,i\x00\x00")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-badPackets:
+		if err == nil {
+			t.Error("ErrorLog called with a nil error")
+		}
+	case <-time.After(time.Second):
+		t.Error("ErrorLog not called for a malformed packet")
+	}
+	select {
+	case got := <-received:
+		t.Errorf("handler called for a malformed packet: %s", got)
+	default:
+	}
+
+	if addr := s.LocalAddr(); addr == nil || addr.String() != conn.LocalAddr().String() {
+		t.Errorf("LocalAddr = %v, want %v", addr, conn.LocalAddr())
+	}
+
+	// Close ends Serve, and the server cannot be served again
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-served:
+		if !errors.Is(err, ErrServerClosed) {
+			t.Errorf("Serve returned %v, want ErrServerClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Serve did not return after Close")
+	}
+	again, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Serve(again); !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Serve after Close returned %v, want ErrServerClosed", err)
+	}
+}
@@ -0,0 +1,231 @@
+package osc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// Framing is the way packets are delimited on a stream transport such as TCP
+type Framing int
+
+const (
+	// FramingLength prefixes each packet with its int32 size, as in OSC 1.0
+	FramingLength Framing = iota
+	// FramingSLIP wraps each packet in double-ended SLIP, as in OSC 1.1
+	FramingSLIP
+)
+
+// SLIP special bytes, RFC 1055
+const (
+	slipEnd    = 0xC0
+	slipEsc    = 0xDB
+	slipEscEnd = 0xDC
+	slipEscEsc = 0xDD
+)
+
+// maxFrameSize is the largest packet a stream may carry.
+// A read which fills a MaxPacketSize buffer is taken as truncated,
+// so a packet must be at least one byte shorter to arrive whole
+const maxFrameSize = MaxPacketSize - 1
+
+// StreamConn carries OSC packets over a stream connection.
+// Each Write sends one framed packet and each Read returns one whole packet,
+// so a StreamConn can be used with Send, Listen, Inquire and NewClient
+// exactly as a UDP connection is
+type StreamConn struct {
+	net.Conn
+	framing Framing
+	r       *bufio.Reader
+	wmu     sync.Mutex
+
+	// A frame part way through being read is kept here when a read times out,
+	//     so the next Read carries on from where it stopped
+	rmu      sync.Mutex
+	frame    []byte
+	header   [4]byte
+	headerN  int  // bytes of the length prefix read so far
+	escaped  bool // SLIP: the last byte read was an escape
+	skipping bool // SLIP: dropping the rest of a bad frame
+	broken   error
+}
+
+func NewStreamConn(conn net.Conn, framing Framing) *StreamConn {
+	return &StreamConn{
+		Conn:    conn,
+		framing: framing,
+		r:       bufio.NewReader(conn),
+	}
+}
+
+func DialTCP(remoteAddr string, framing Framing) (*StreamConn, error) {
+	// Takes a remote address and returns a StreamConn over TCP
+	//     remoteAddr should be provided in the form: "ip:port"
+	conn, err := net.Dial("tcp", remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+	return NewStreamConn(conn, framing), nil
+}
+
+func (sc *StreamConn) Write(byt []byte) (int, error) {
+	// Frame the packet and write it in a single call
+	//     so packets from concurrent writers never interleave
+	var frame []byte
+	switch sc.framing {
+	case FramingSLIP:
+		frame = slipEncode(byt)
+	default:
+		frame = append(int32ToBytes(int32(len(byt))), byt...)
+	}
+	sc.wmu.Lock()
+	defer sc.wmu.Unlock()
+	_, err := sc.Conn.Write(frame)
+	if err != nil {
+		return 0, err
+	}
+	return len(byt), nil
+}
+
+func (sc *StreamConn) Read(byt []byte) (int, error) {
+	// Reads one whole packet into byt
+	//     Returns ErrPacketTruncated if the packet is larger than byt
+	//   If the read deadline passes part way through a packet,
+	//     the next Read finishes that packet
+	sc.rmu.Lock()
+	defer sc.rmu.Unlock()
+	if sc.broken != nil {
+		return 0, sc.broken
+	}
+	frame, err := sc.readFrame()
+	if err != nil {
+		return 0, err
+	}
+	n := copy(byt, frame)
+	if n < len(frame) {
+		return n, ErrPacketTruncated
+	}
+	return n, nil
+}
+
+func (sc *StreamConn) readFrame() ([]byte, error) {
+	// The frame returned is only valid until the next call
+	switch sc.framing {
+	case FramingSLIP:
+		return sc.readSLIP()
+	default:
+		return sc.readLengthPrefixed()
+	}
+}
+
+func (sc *StreamConn) readLengthPrefixed() ([]byte, error) {
+	for sc.headerN < len(sc.header) {
+		n, err := sc.r.Read(sc.header[sc.headerN:])
+		sc.headerN += n
+		if err != nil {
+			if errors.Is(err, io.EOF) && sc.headerN > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	size := int(bytesToInt32(sc.header[:]))
+	if size < 0 || size > maxFrameSize {
+		// Nothing marks where the next packet starts,
+		//     so the stream cannot be read any further
+		//   It wraps net.ErrClosed so readers such as Client stop for good
+		sc.broken = fmt.Errorf("invalid packet size %d: %w", size, net.ErrClosed)
+		sc.Conn.Close()
+		return nil, sc.broken
+	}
+	if cap(sc.frame) < size {
+		sc.frame = make([]byte, 0, size)
+	}
+	for len(sc.frame) < size {
+		n, err := sc.r.Read(sc.frame[len(sc.frame):size])
+		sc.frame = sc.frame[:len(sc.frame)+n]
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	frame := sc.frame
+	sc.frame = sc.frame[:0]
+	sc.headerN = 0
+	return frame, nil
+}
+
+func (sc *StreamConn) readSLIP() ([]byte, error) {
+	// Read until an END byte, skipping the empty frames
+	//     which sit between the two END bytes of adjacent packets
+	//   A frame which is too long or badly escaped is dropped up to
+	//     its END byte, so the next Read starts on the following packet
+	for {
+		b, err := sc.r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) && (len(sc.frame) > 0 || sc.escaped) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if sc.skipping {
+			if b == slipEnd {
+				sc.skipping = false
+			}
+			continue
+		}
+		if sc.escaped {
+			sc.escaped = false
+			switch b {
+			case slipEscEnd:
+				b = slipEnd
+			case slipEscEsc:
+				b = slipEsc
+			default:
+				sc.frame = sc.frame[:0]
+				sc.skipping = b != slipEnd
+				return nil, fmt.Errorf("invalid SLIP escape 0x%02x", b)
+			}
+		} else {
+			switch b {
+			case slipEnd:
+				if len(sc.frame) == 0 {
+					continue
+				}
+				frame := sc.frame
+				sc.frame = sc.frame[:0]
+				return frame, nil
+			case slipEsc:
+				sc.escaped = true
+				continue
+			}
+		}
+		if len(sc.frame) >= maxFrameSize {
+			sc.frame = sc.frame[:0]
+			sc.skipping = true
+			return nil, ErrPacketTruncated
+		}
+		sc.frame = append(sc.frame, b)
+	}
+}
+
+func slipEncode(byt []byte) []byte {
+	frame := make([]byte, 0, len(byt)+2)
+	frame = append(frame, slipEnd)
+	for _, b := range byt {
+		switch b {
+		case slipEnd:
+			frame = append(frame, slipEsc, slipEscEnd)
+		case slipEsc:
+			frame = append(frame, slipEsc, slipEscEsc)
+		default:
+			frame = append(frame, b)
+		}
+	}
+	return append(frame, slipEnd)
+}
@@ -0,0 +1,149 @@
+package osc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// readerConn is a conn which reads from a fixed stream
+type readerConn struct {
+	net.Conn
+	io.Reader
+}
+
+func (rc readerConn) Read(b []byte) (int, error) {
+	return rc.Reader.Read(b)
+}
+
+func (rc readerConn) Close() error {
+	return nil
+}
+
+func streamFrame(t *testing.T, framing Framing, address string) []byte {
+	// Returns the message at address as framed on the stream
+	t.Helper()
+	msg := NewMessage(address)
+	if err := msg.MakePacket(); err != nil {
+		t.Fatal(err)
+	}
+	if framing == FramingSLIP {
+		return slipEncode(msg.Bytes())
+	}
+	return append(int32ToBytes(int32(len(msg.Bytes()))), msg.Bytes()...)
+}
+
+func readAddress(t *testing.T, sc *StreamConn) string {
+	t.Helper()
+	buf := make([]byte, MaxPacketSize)
+	n, err := sc.Read(buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	var msg Message
+	if err := msg.ParseView(buf[:n]); err != nil {
+		t.Fatal(err)
+	}
+	return string(msg.Address)
+}
+
+func TestStreamConnTimeoutMidFrame(t *testing.T) {
+	for _, framing := range []Framing{FramingLength, FramingSLIP} {
+		client, server := net.Pipe()
+		defer client.Close()
+		defer server.Close()
+		sc := NewStreamConn(client, framing)
+
+		first := streamFrame(t, framing, "/ch/01/mix/fader")
+		second := streamFrame(t, framing, "/ch/02/mix/fader")
+		// Splitting within the length prefix, then within the packet
+		for _, cut := range []int{2, 6} {
+			go server.Write(first[:cut])
+			sc.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+			buf := make([]byte, MaxPacketSize)
+			if _, err := sc.Read(buf); !isTimeout(err) {
+				t.Fatalf("framing %d, cut %d: Read = %v, want a timeout", framing, cut, err)
+			}
+			sc.SetReadDeadline(time.Time{})
+			go func() {
+				server.Write(first[cut:])
+				server.Write(second)
+			}()
+			if got := readAddress(t, sc); got != "/ch/01/mix/fader" {
+				t.Errorf("framing %d, cut %d: after a timeout read %s, want /ch/01/mix/fader", framing, cut, got)
+			}
+			if got := readAddress(t, sc); got != "/ch/02/mix/fader" {
+				t.Errorf("framing %d, cut %d: then read %s, want /ch/02/mix/fader", framing, cut, got)
+			}
+		}
+	}
+}
+
+func TestStreamConnBadFrames(t *testing.T) {
+	next := streamFrame(t, FramingSLIP, "/next")
+
+	// An oversize SLIP frame is dropped, and reading carries on after it
+	stream := []byte{slipEnd}
+	stream = append(stream, make([]byte, MaxPacketSize)...)
+	stream = append(stream, slipEnd)
+	stream = append(stream, next...)
+	sc := NewStreamConn(readerConn{Reader: bytes.NewReader(stream)}, FramingSLIP)
+	buf := make([]byte, MaxPacketSize)
+	if _, err := sc.Read(buf); !errors.Is(err, ErrPacketTruncated) {
+		t.Errorf("oversize SLIP frame: Read = %v, want ErrPacketTruncated", err)
+	}
+	if got := readAddress(t, sc); got != "/next" {
+		t.Errorf("after an oversize SLIP frame read %s, want /next", got)
+	}
+
+	// So is a badly escaped one
+	stream = []byte{slipEnd, '/', 'a', slipEsc, 'x', 'y', slipEnd}
+	stream = append(stream, next...)
+	sc = NewStreamConn(readerConn{Reader: bytes.NewReader(stream)}, FramingSLIP)
+	if _, err := sc.Read(buf); err == nil {
+		t.Error("bad SLIP escape: Read succeeded")
+	}
+	if got := readAddress(t, sc); got != "/next" {
+		t.Errorf("after a bad SLIP escape read %s, want /next", got)
+	}
+
+	// An oversize length cannot be skipped, so the stream is closed
+	client, server := net.Pipe()
+	defer server.Close()
+	sc = NewStreamConn(client, FramingLength)
+	go func() {
+		server.Write(int32ToBytes(MaxPacketSize))
+		server.Write(streamFrame(t, FramingLength, "/next"))
+	}()
+	if _, err := sc.Read(buf); err == nil {
+		t.Fatal("oversize length: Read succeeded")
+	}
+	if _, err := sc.Read(buf); err == nil {
+		t.Error("Read after an oversize length succeeded")
+	}
+	if _, err := client.Write([]byte{0}); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("write after an oversize length: %v, want the conn closed", err)
+	}
+
+	// The largest frame either framing allows arrives whole,
+	//     as a read fills less than a MaxPacketSize buffer
+	largest := make([]byte, maxFrameSize)
+	for framing, stream := range map[Framing][]byte{
+		FramingSLIP:   slipEncode(largest),
+		FramingLength: append(int32ToBytes(maxFrameSize), largest...),
+	} {
+		sc := NewStreamConn(readerConn{Reader: bytes.NewReader(stream)}, framing)
+		byt, release, err := readPacket(sc)
+		if err != nil {
+			t.Errorf("largest frame, framing %d: %v", framing, err)
+			continue
+		}
+		if len(byt) != maxFrameSize {
+			t.Errorf("largest frame, framing %d: read %d bytes, want %d", framing, len(byt), maxFrameSize)
+		}
+		release()
+	}
+}
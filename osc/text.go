@@ -0,0 +1,254 @@
+package osc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The text format is one message per line, as used by the X32 command line tools:
+//
+//	/ch/01/mix/fader ,f 0.75
+//	/ch/01/config/name ,s "Lead Vox"
+//	/info
+//
+// The address comes first, then the type tags with their leading comma,
+// then one value for each type tag that carries data.
+// Strings holding spaces or quotes are double quoted, blobs, RGBA colours
+// and MIDI messages are written in hex, and chars as quoted runes like 'a'
+
+func (msg *Message) Format() string {
+	// Returns the message in the one line text format
+	var sb strings.Builder
+	sb.WriteString(formatString(string(trimZeroBytesRight(msg.Address))))
+	if len(msg.Arguments) == 0 {
+		return sb.String()
+	}
+	sb.WriteString(" ,")
+	for _, arg := range msg.Arguments {
+		sb.WriteByte(arg.TypeTag)
+	}
+	for _, arg := range msg.Arguments {
+		value, ok := formatArgument(arg)
+		if ok {
+			sb.WriteByte(' ')
+			sb.WriteString(value)
+		}
+	}
+	return sb.String()
+}
+
+func formatArgument(arg argument) (value string, ok bool) {
+	// Returns the text of an argument's value
+	//     ok is false for the types which carry no data
+	if size, fixed := argumentSize[arg.TypeTag]; fixed && len(arg.Data) != size {
+		return "?", true
+	}
+	switch arg.TypeTag {
+	case 'i':
+		return strconv.FormatInt(int64(byteToInt32(arg.Data)), 10), true
+	case 'h':
+		return strconv.FormatInt(int64(bytesToUint64(arg.Data)), 10), true
+	case 'f':
+		return strconv.FormatFloat(float64(byteToFloat32(arg.Data)), 'g', -1, 32), true
+	case 'd':
+		return strconv.FormatFloat(bytesToFloat64(arg.Data), 'g', -1, 64), true
+	case 's', 'S':
+		return formatString(string(arg.Data)), true
+	case 'b', 'r', 'm':
+		// An empty blob still needs a token
+		if len(arg.Data) == 0 {
+			return `""`, true
+		}
+		return hex.EncodeToString(arg.Data), true
+	case 't':
+		return fmt.Sprintf("0x%016x", bytesToUint64(arg.Data)), true
+	case 'c':
+		return strconv.QuoteRune(rune(byteToInt32(arg.Data))), true
+	}
+	return "", false
+}
+
+func formatString(s string) string {
+	// Quote a string only when it would not survive as a single bare word
+	if s == "" || strings.ContainsAny(s, "\"'\\") || strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func ParseText(s string) (msg Message, err error) {
+	// Parses a message in the one line text format, the reverse of Format
+	tokens, err := splitText(s)
+	if err != nil {
+		return msg, err
+	}
+	if len(tokens) == 0 || tokens[0] == "" {
+		return msg, fmt.Errorf("no address")
+	}
+	if strings.IndexByte(tokens[0], 0) >= 0 {
+		return msg, fmt.Errorf("address cannot contain a zero byte")
+	}
+	msg = NewMessage(tokens[0])
+	if len(tokens) == 1 {
+		return msg, nil
+	}
+	typeTags, ok := strings.CutPrefix(tokens[1], ",")
+	if !ok {
+		return msg, fmt.Errorf("type tags must begin with ','")
+	}
+	values := tokens[2:]
+	for _, typeTag := range []byte(typeTags) {
+		// Types without data take no value
+		switch typeTag {
+		case 'T':
+			msg.AddBool(true)
+			continue
+		case 'F':
+			msg.AddBool(false)
+			continue
+		case 'N':
+			msg.AddNil()
+			continue
+		case 'I':
+			msg.AddImpulse()
+			continue
+		case '[':
+			msg.BeginArray()
+			continue
+		case ']':
+			msg.EndArray()
+			continue
+		}
+		if len(values) == 0 {
+			return msg, fmt.Errorf("no value for '%c' argument", typeTag)
+		}
+		err = addTextArgument(&msg, typeTag, values[0])
+		if err != nil {
+			return msg, fmt.Errorf("'%c' argument %q: %w", typeTag, values[0], err)
+		}
+		values = values[1:]
+	}
+	if len(values) > 0 {
+		return msg, fmt.Errorf("%d values without a type tag", len(values))
+	}
+	return msg, nil
+}
+
+func addTextArgument(msg *Message, typeTag byte, value string) error {
+	switch typeTag {
+	case 'i':
+		x, err := strconv.ParseInt(value, 0, 32)
+		if err != nil {
+			return err
+		}
+		msg.AddInt(int32(x))
+	case 'h':
+		x, err := strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			return err
+		}
+		msg.AddInt64(x)
+	case 'f':
+		x, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return err
+		}
+		msg.AddFloat(float32(x))
+	case 'd':
+		x, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		msg.AddDouble(x)
+	case 's', 'S':
+		// A zero byte would end the string early
+		if strings.IndexByte(value, 0) >= 0 {
+			return fmt.Errorf("string cannot contain a zero byte")
+		}
+		if typeTag == 's' {
+			msg.AddString(value)
+		} else {
+			msg.AddSymbol(value)
+		}
+	case 'b':
+		byt, err := hex.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		msg.AddBlob(byt)
+	case 't':
+		x, err := strconv.ParseUint(value, 0, 64)
+		if err != nil {
+			return err
+		}
+		msg.AddTimetag(Timetag(x))
+	case 'c':
+		r := []rune(value)
+		if len(r) != 1 {
+			return fmt.Errorf("not a single character")
+		}
+		msg.AddChar(Char(r[0]))
+	case 'r', 'm':
+		byt, err := hex.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		if len(byt) != 4 {
+			return fmt.Errorf("not 4 bytes")
+		}
+		if typeTag == 'r' {
+			msg.AddRGBA(color.RGBA{R: byt[0], G: byt[1], B: byt[2], A: byt[3]})
+		} else {
+			msg.AddMIDI(MIDI{Port: byt[0], Status: byt[1], Data1: byt[2], Data2: byt[3]})
+		}
+	default:
+		return ErrUnknownTypeTag
+	}
+	return nil
+}
+
+func splitText(s string) (tokens []string, err error) {
+	// Splits s on white space
+	//     A token in double or single quotes is unquoted as a Go string or rune,
+	//     and may contain white space
+	s = strings.TrimSpace(s)
+	for len(s) > 0 {
+		var token string
+		switch s[0] {
+		case '"', '\'':
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("bad quoting: %s", s)
+			}
+			s = s[len(quoted):]
+			if quoted[0] == '"' {
+				token, err = strconv.Unquote(quoted)
+			} else {
+				var r rune
+				r, _, _, err = strconv.UnquoteChar(quoted[1:len(quoted)-1], '\'')
+				token = string(r)
+			}
+			if err != nil {
+				return nil, err
+			}
+		default:
+			end := strings.IndexFunc(s, unicode.IsSpace)
+			if end < 0 {
+				end = len(s)
+			}
+			token, s = s[:end], s[end:]
+		}
+		if len(s) > 0 && !unicode.IsSpace(rune(s[0])) {
+			return nil, fmt.Errorf("missing space after %q", token)
+		}
+		tokens = append(tokens, token)
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,43 @@
+package osc
+
+import (
+	"time"
+)
+
+// Timetag is an OSC time tag: a 64 bit NTP timestamp.
+// The top 32 bits are seconds since 1 Jan 1900,
+// the bottom 32 bits are fractions of a second
+type Timetag uint64
+
+// Immediately is the special time tag which tells the receiver
+// to act on the contents of a bundle as soon as it arrives
+const Immediately Timetag = 1
+
+// Seconds between the NTP epoch (1900) and the unix epoch (1970)
+const ntpEpochOffset = 2208988800
+
+func NewTimetag(t time.Time) Timetag {
+	// Converts a time.Time into an NTP time tag
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := (uint64(t.Nanosecond()) << 32) / uint64(time.Second)
+	return Timetag(secs<<32 | frac)
+}
+
+func (tt Timetag) Time() time.Time {
+	// Converts the time tag into a time.Time
+	//     Immediately is reported as the current time
+	if tt == Immediately {
+		return time.Now()
+	}
+	secs := int64(tt>>32) - ntpEpochOffset
+	nsecs := (int64(tt&0xffffffff) * int64(time.Second)) >> 32
+	return time.Unix(secs, nsecs)
+}
+
+func (tt Timetag) Seconds() uint32 {
+	return uint32(tt >> 32)
+}
+
+func (tt Timetag) Fraction() uint32 {
+	return uint32(tt)
+}
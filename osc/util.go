@@ -41,6 +41,11 @@ func zeroBytesToAdd(n int) int {
 	return 4 - (n % 4)
 }
 
+func blobPadding(n int) int {
+	// Unlike strings, a blob needs no zero bytes if already divisible by 4
+	return (4 - (n % 4)) % 4
+}
+
 func bytesToInt32(b []byte) int32 {
 	return int32(binary.BigEndian.Uint32((b)[:]))
 }
@@ -79,3 +84,21 @@ func allElementsZero(b []byte) bool {
 	}
 	return true
 }
+
+func uint64ToBytes(u uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], u)
+	return buf[:]
+}
+
+func bytesToUint64(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b[:])
+}
+
+func float64ToBytes(f float64) []byte {
+	return uint64ToBytes(math.Float64bits(f))
+}
+
+func bytesToFloat64(b []byte) float64 {
+	return math.Float64frombits(bytesToUint64(b))
+}
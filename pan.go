@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// Pan is shown on the console from -100, hard left, to +100, hard right,
+// and sent as a float from 0 to 1 where 0.5 is the centre.
+// On the main stereo bus it is the balance
+const maxPan = 100
+
+func panToFloat(pan float64) float32 {
+	pan = min(max(pan, -maxPan), maxPan)
+	return float32((pan + maxPan) / (2 * maxPan))
+}
+
+func floatToPan(f float32) float64 {
+	return float64(f)*2*maxPan - maxPan
+}
+
+func (m *mixer) getPan(channelID int) (float64, error) {
+	path := getPanPath(channelID)
+	if path == "" {
+		return 0, fmt.Errorf("channelID %d has no pan", channelID)
+	}
+	reply, err := m.query(path)
+	if err != nil {
+		return 0, err
+	}
+	f, err := reply.ArgFloat(0)
+	if err != nil {
+		return 0, fmt.Errorf("could not get pan of channelID %d: %w", channelID, err)
+	}
+	return floatToPan(f), nil
+}
+
+func (m *mixer) setPan(channelID int, pan float64) error {
+	// Pans the channel from -100 to +100
+	//     A linked pair shares its pan, which acts as a balance
+	if getPanPath(channelID) == "" {
+		return fmt.Errorf("channelID %d has no pan", channelID)
+	}
+	return m.sendLinked(channelID, getPanPath, func(msg *osc.Message) {
+		msg.AddFloat(panToFloat(pan))
+	})
+}
+
+// Odd and even neighbours can be linked into a stereo pair,
+// e.g. channels 1 and 2 by /config/chlink/1-2
+var linkGroups = []struct {
+	first int // channelID of the first channel which can be linked
+	count int
+	name  string
+}{
+	{0, 32, "chlink"},
+	{32, 8, "auxlink"},
+	{40, 8, "fxlink"},
+	{48, 16, "buslink"},
+	{64, 6, "mtxlink"},
+}
+
+func getLinkPath(ch int) (path string, partner int) {
+	// Returns the link switch of the pair holding the channelID
+	//     and the other channelID of the pair
+	//   Returns "" for channels which cannot be linked
+	for _, g := range linkGroups {
+		if ch < g.first || ch >= g.first+g.count {
+			continue
+		}
+		i := ch - g.first
+		odd := i - i%2 + 1 // e.g. channel 1 of 1-2
+		return fmt.Sprintf("/config/%s/%d-%d", g.name, odd, odd+1), g.first + (i ^ 1)
+	}
+	return "", -1
+}
+
+func (m *mixer) isLinked(channelID int) (bool, error) {
+	path, _ := getLinkPath(channelID)
+	if path == "" {
+		return false, nil
+	}
+	reply, err := m.query(path)
+	if err != nil {
+		return false, err
+	}
+	on, err := reply.ArgInt(0)
+	if err != nil {
+		return false, fmt.Errorf("could not get link of channelID %d: %w", channelID, err)
+	}
+	return on != 0, nil
+}
+
+func (m *mixer) setLink(channelID int, linked bool) error {
+	// Links, or unlinks, the channel with its odd or even neighbour
+	path, _ := getLinkPath(channelID)
+	if path == "" {
+		return fmt.Errorf("channelID %d cannot be linked", channelID)
+	}
+	msg := osc.NewMessage(path)
+	msg.AddInt(boolToInt(linked))
+	return m.send(msg)
+}
+
+func (m *mixer) withPartner(channelID int) []int {
+	// Returns the channelID and, when it is linked, its partner
+	//     The console keeps a linked pair in step only for changes
+	//     made on its surface, so we change both sides ourselves
+	//   When the link cannot be read the channel acts alone
+	linked, err := m.isLinked(channelID)
+	if err != nil || !linked {
+		return []int{channelID}
+	}
+	_, partner := getLinkPath(channelID)
+	return []int{channelID, partner}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLinkPath(t *testing.T) {
+	for _, tc := range []struct {
+		ch      int
+		path    string
+		partner int
+	}{
+		{0, "/config/chlink/1-2", 1},
+		{1, "/config/chlink/1-2", 0},
+		{31, "/config/chlink/31-32", 30},
+		{33, "/config/auxlink/1-2", 32},
+		{46, "/config/fxlink/7-8", 47},
+		{63, "/config/buslink/15-16", 62},
+		{68, "/config/mtxlink/5-6", 69},
+		{70, "", -1},
+		{72, "", -1},
+	} {
+		path, partner := getLinkPath(tc.ch)
+		if path != tc.path || partner != tc.partner {
+			t.Errorf("getLinkPath(%d) = %q, %d; want %q, %d", tc.ch, path, partner, tc.path, tc.partner)
+		}
+	}
+}
+
+func TestPan(t *testing.T) {
+	m, console := newTestMixer(t)
+	if pan, err := m.getPan(0); err != nil || pan != 0 {
+		t.Fatalf("pan = %v, %v; want centre", pan, err)
+	}
+	if err := m.setPan(0, -50); err != nil {
+		t.Fatal(err)
+	}
+	if pan, err := m.getPan(0); err != nil || pan != -50 {
+		t.Errorf("pan = %v, %v; want -50", pan, err)
+	}
+	// Unlinked, the neighbour stays put
+	eventually(t, "pan to reach the console", func() bool {
+		return consoleFloat(t, console, "/ch/01/mix/pan") == 0.25
+	})
+	if f := consoleFloat(t, console, "/ch/02/mix/pan"); f != 0.5 {
+		t.Errorf("unlinked neighbour panned to %v", f)
+	}
+	if _, err := m.getPan(64); err == nil {
+		t.Error("a matrix has a pan")
+	}
+	if err := m.setPan(70, 100); err != nil {
+		t.Errorf("balance: %v", err)
+	}
+}
+
+func TestLinkedPair(t *testing.T) {
+	m, console := newTestMixer(t)
+	if err := m.setLink(49, true); err != nil {
+		t.Fatal(err)
+	}
+	if linked, err := m.isLinked(48); err != nil || !linked {
+		t.Fatalf("bus 1 linked = %v, %v; want linked", linked, err)
+	}
+
+	// Both sides follow a change to either
+	if err := m.setPan(49, 30); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.setMute(48, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.fadeTo(48, 0.5, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "pair to reach the console", func() bool {
+		for _, bus := range []string{"/bus/01", "/bus/02"} {
+			msg, _ := console.Get(bus + "/mix/on")
+			if on, _ := msg.ArgInt(0); on != 0 {
+				return false
+			}
+			if consoleFloat(t, console, bus+"/mix/pan") != panToFloat(30) {
+				return false
+			}
+			if math.Abs(float64(consoleFloat(t, console, bus+"/mix/fader")-0.5)) > 1/float64(m.faderResolution) {
+				return false
+			}
+		}
+		return true
+	})
+}
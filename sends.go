@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// Every input sends to the 16 mix buses, e.g. /ch/01/mix/03/level,
+// and every bus and main sends to the 6 matrices, e.g. /bus/01/mix/03/level.
+// Send levels follow the fader law.
+// Only odd numbered sends have a pan and a tap point,
+// which they share with the even send after them
+const (
+	busSendCount    = 16
+	matrixSendCount = 6
+)
+
+// The tap point of a send, /.../mix/NN/type
+const (
+	sendInLC      = 0 // after the input and low cut
+	sendPreEQ     = 1
+	sendPostEQ    = 2
+	sendPreFader  = 3
+	sendPostFader = 4
+	sendSubgroup  = 5 // the send follows the bus's own fader, as a subgroup
+	sendTypeCount = 6
+)
+
+func sendCount(ch int) int {
+	switch {
+	case ch < 0:
+		return 0
+	case ch < 48: // channels, aux ins and fx returns
+		return busSendCount
+	case ch < 64, ch == 70, ch == 71: // buses and mains
+		return matrixSendCount
+	default:
+		return 0
+	}
+}
+
+func getSendPath(ch, send int) string {
+	// Returns the prefix of the send, e.g. "/ch/01/mix/03"
+	//     Sends are numbered from 1 as the buses and matrices are
+	if send < 1 || send > sendCount(ch) {
+		return ""
+	}
+	return fmt.Sprintf("%s/mix/%02d", getChannelIDPath(ch), send)
+}
+
+func getSendPairPath(ch, send int) string {
+	// Returns the odd send of the pair, which holds its pan and tap point
+	if getSendPath(ch, send) == "" {
+		return ""
+	}
+	return getSendPath(ch, pairSend(send))
+}
+
+func pairSend(send int) int {
+	// Returns the odd send of the pair, e.g. 5 for sends 5 and 6
+	//     Only for sends in range, as Go's % keeps the sign and 0 would give 1
+	return send - (send-1)%2
+}
+
+func (m *mixer) getSendLevel(channelID, send int) (float32, error) {
+	path := getSendPath(channelID, send)
+	if path == "" {
+		return 0, fmt.Errorf("channelID %d has no send %d", channelID, send)
+	}
+	reply, err := m.query(path + "/level")
+	if err != nil {
+		return 0, err
+	}
+	level, err := reply.ArgFloat(0)
+	if err != nil {
+		return 0, fmt.Errorf("could not get send %d of channelID %d: %w", send, channelID, err)
+	}
+	return level, nil
+}
+
+func (m *mixer) setSendLevel(channelID, send int, level float32) error {
+	// Sets the send of the channel and its linked partner
+	//     level is a fader value, see dbToFader
+	return m.setSend(channelID, send, "/level", func(msg *osc.Message) {
+		msg.AddFloat(level)
+	})
+}
+
+func (m *mixer) isSendOn(channelID, send int) (bool, error) {
+	path := getSendPath(channelID, send)
+	if path == "" {
+		return false, fmt.Errorf("channelID %d has no send %d", channelID, send)
+	}
+	reply, err := m.query(path + "/on")
+	if err != nil {
+		return false, err
+	}
+	on, err := reply.ArgInt(0)
+	if err != nil {
+		return false, fmt.Errorf("could not get send %d of channelID %d: %w", send, channelID, err)
+	}
+	return on != 0, nil
+}
+
+func (m *mixer) setSendOn(channelID, send int, on bool) error {
+	return m.setSend(channelID, send, "/on", func(msg *osc.Message) {
+		msg.AddInt(boolToInt(on))
+	})
+}
+
+func (m *mixer) getSendPan(channelID, send int) (float64, error) {
+	path := getSendPairPath(channelID, send)
+	if path == "" {
+		return 0, fmt.Errorf("channelID %d has no send %d", channelID, send)
+	}
+	reply, err := m.query(path + "/pan")
+	if err != nil {
+		return 0, err
+	}
+	f, err := reply.ArgFloat(0)
+	if err != nil {
+		return 0, fmt.Errorf("could not get send %d pan of channelID %d: %w", send, channelID, err)
+	}
+	return floatToPan(f), nil
+}
+
+func (m *mixer) setSendPan(channelID, send int, pan float64) error {
+	// Pans the send pair from -100 to +100
+	if getSendPath(channelID, send) == "" {
+		return fmt.Errorf("channelID %d has no send %d", channelID, send)
+	}
+	return m.setSend(channelID, pairSend(send), "/pan", func(msg *osc.Message) {
+		msg.AddFloat(panToFloat(pan))
+	})
+}
+
+func (m *mixer) getSendType(channelID, send int) (int, error) {
+	path := getSendPairPath(channelID, send)
+	if path == "" {
+		return 0, fmt.Errorf("channelID %d has no send %d", channelID, send)
+	}
+	reply, err := m.query(path + "/type")
+	if err != nil {
+		return 0, err
+	}
+	sendType, err := reply.ArgInt(0)
+	if err != nil {
+		return 0, fmt.Errorf("could not get send %d tap point of channelID %d: %w", send, channelID, err)
+	}
+	return int(sendType), nil
+}
+
+func (m *mixer) setSendType(channelID, send int, sendType int) error {
+	// Sets the tap point of the send pair, e.g. sendPreFader
+	if sendType < 0 || sendType >= sendTypeCount {
+		return fmt.Errorf("no send type %d", sendType)
+	}
+	if getSendPath(channelID, send) == "" {
+		return fmt.Errorf("channelID %d has no send %d", channelID, send)
+	}
+	return m.setSend(channelID, pairSend(send), "/type", func(msg *osc.Message) {
+		msg.AddInt(int32(sendType))
+	})
+}
+
+func (m *mixer) setSend(channelID, send int, param string, addValue func(msg *osc.Message)) error {
+	// Writes param of the send on the channel and its linked partner
+	if getSendPath(channelID, send) == "" {
+		return fmt.Errorf("channelID %d has no send %d", channelID, send)
+	}
+	return m.sendLinked(channelID, func(id int) string {
+		return getSendPath(id, send) + param
+	}, addValue)
+}
+
+func (m *mixer) fadeSendTo(channelID, send int, target float32, fadeDuration time.Duration) error {
+	// Fade the send of the given channel, and of its linked partner,
+	//     from its current level to the target over fadeDuration
+	//   Like a fader fade, it is stopped by killSwitch on the channel
+	if getSendPath(channelID, send) == "" {
+		return fmt.Errorf("channelID %d has no send %d", channelID, send)
+	}
+	f := m.faders[channelID].sends[send-1]
+	if !f.claim() {
+		return fmt.Errorf("send currently in motion")
+	}
+	defer f.deactivate()
+	current, err := m.getSendLevel(channelID, send)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, id := range m.withPartner(channelID) {
+		paths = append(paths, getSendPath(id, send)+"/level")
+	}
+	return m.fadePaths(f, paths, current, target, fadeDuration)
+}
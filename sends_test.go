@@ -0,0 +1,131 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSendPaths(t *testing.T) {
+	for _, tc := range []struct {
+		ch, send int
+		path     string
+		pair     string
+	}{
+		{0, 1, "/ch/01/mix/01", "/ch/01/mix/01"},
+		{0, 16, "/ch/01/mix/16", "/ch/01/mix/15"},
+		{47, 4, "/fxrtn/08/mix/04", "/fxrtn/08/mix/03"},
+		{48, 6, "/bus/01/mix/06", "/bus/01/mix/05"},
+		{70, 1, "/main/st/mix/01", "/main/st/mix/01"},
+		{0, 17, "", ""},
+		{0, 0, "", ""},
+		{0, -1, "", ""},
+		{48, 7, "", ""},
+		{64, 1, "", ""},
+		{72, 1, "", ""},
+	} {
+		if got := getSendPath(tc.ch, tc.send); got != tc.path {
+			t.Errorf("getSendPath(%d, %d) = %q, want %q", tc.ch, tc.send, got, tc.path)
+		}
+		if got := getSendPairPath(tc.ch, tc.send); got != tc.pair {
+			t.Errorf("getSendPairPath(%d, %d) = %q, want %q", tc.ch, tc.send, got, tc.pair)
+		}
+	}
+}
+
+func TestSends(t *testing.T) {
+	m, console := newTestMixer(t)
+	if err := m.setSendLevel(2, 5, dbToFader(-10)); err != nil {
+		t.Fatal(err)
+	}
+	if level, err := m.getSendLevel(2, 5); err != nil || level != 0.5 {
+		t.Errorf("send level = %v, %v; want 0.5", level, err)
+	}
+	if err := m.setSendOn(2, 5, false); err != nil {
+		t.Fatal(err)
+	}
+	if on, err := m.isSendOn(2, 5); err != nil || on {
+		t.Errorf("send on = %v, %v; want off", on, err)
+	}
+	// Pan and tap point are shared by the pair of sends 5 and 6
+	if err := m.setSendPan(2, 6, 40); err != nil {
+		t.Fatal(err)
+	}
+	if pan, err := m.getSendPan(2, 5); err != nil || math.Abs(pan-40) > 1e-4 {
+		t.Errorf("send pan = %v, %v; want 40", pan, err)
+	}
+	if err := m.setSendType(2, 6, sendPreFader); err != nil {
+		t.Fatal(err)
+	}
+	if sendType, err := m.getSendType(2, 5); err != nil || sendType != sendPreFader {
+		t.Errorf("send type = %v, %v; want pre fader", sendType, err)
+	}
+	if err := m.setSendType(2, 5, 9); err == nil {
+		t.Error("set an unknown send type")
+	}
+	// Send 0 is not send 1, whose pair math it would otherwise reach
+	if err := m.setSendPan(2, 0, 40); err == nil {
+		t.Error("set the pan of send 0")
+	}
+	if err := m.setSendType(2, 0, sendPostFader); err == nil {
+		t.Error("set the tap point of send 0")
+	}
+	if _, err := m.getSendType(2, 0); err == nil {
+		t.Error("got the tap point of send 0")
+	}
+	// Buses have only the 6 matrix sends
+	if err := m.setSendPan(48, 7, 40); err == nil {
+		t.Error("set the pan of matrix send 7")
+	}
+	eventually(t, "sends to reach the console", func() bool {
+		msg, _ := console.Get("/ch/03/mix/05/type")
+		sendType, _ := msg.ArgInt(0)
+		return sendType == sendPreFader && consoleFloat(t, console, "/ch/03/mix/05/level") == 0.5
+	})
+
+	// Matrix sends from a bus
+	if err := m.setSendLevel(48, 2, 0.75); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "matrix send", func() bool {
+		return consoleFloat(t, console, "/bus/01/mix/02/level") == 0.75
+	})
+}
+
+func TestFadeSend(t *testing.T) {
+	m, console := newTestMixer(t)
+	if err := m.fadeSendTo(0, 16, 0.75, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "send fade", func() bool {
+		level := consoleFloat(t, console, "/ch/01/mix/16/level")
+		return math.Abs(float64(level-0.75)) <= 1/float64(m.faderResolution)
+	})
+	// The fader is left alone
+	if f := consoleFloat(t, console, "/ch/01/mix/fader"); f != 0.75 {
+		t.Errorf("fader moved to %v", f)
+	}
+	if m.faders[0].sends[15].active.Load() {
+		t.Error("send still active after its fade")
+	}
+
+	// killSwitch stops a send fade
+	done := make(chan error, 1)
+	go func() {
+		done <- m.fadeSendTo(1, 1, 1, 2*time.Second)
+	}()
+	eventually(t, "send fade to start", func() bool { return m.faders[1].sends[0].active.Load() })
+	// A second fade of the same send is refused while the first holds it
+	if err := m.fadeSendTo(1, 1, 0, 100*time.Millisecond); err == nil {
+		t.Error("second fade of a send in motion started")
+	}
+	m.killSwitch(1)
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("send fade was not interrupted")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("send fade did not stop")
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// A subscription asks the console to push updates for 10 seconds,
+// and renews the request until it is cancelled.
+// The console offers three kinds:
+// /subscribe streams a single parameter at its own address,
+// /batchsubscribe streams a numbered range of one parameter as a blob,
+// and /formatsubscribe streams parameters named with ** for a two digit index,
+// e.g. /ch/**/mix/fader, as a blob.
+// Blobs arrive at an alias chosen by the subscriber and hold an int32 count
+// followed by that many 32 bit values, all little endian, as meter blobs do
+type subscription struct {
+	client  *osc.Client
+	address string // where updates arrive
+	request osc.Message
+	remove  func() // unregisters this subscription's handler
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func startSubscription(client *osc.Client, address string, request osc.Message, handler func(msg osc.Message)) (*subscription, error) {
+	// Registers the handler for updates at address, sends the request,
+	//     and renews it until cancelled
+	if client == nil {
+		return nil, fmt.Errorf("no connection made")
+	}
+	// Updates arrive on the client as unsolicited messages
+	s := &subscription{
+		client:  client,
+		address: address,
+		request: request,
+		remove:  client.HandleFunc(address, handler),
+		stop:    make(chan struct{}),
+	}
+	err := client.Send(request)
+	if err != nil {
+		s.cancel()
+		return nil, err
+	}
+	go s.renew(renewInterval)
+	return s, nil
+}
+
+func (s *subscription) renew(interval time.Duration) {
+	// Re-send the request before it expires
+	//     Sending the full request, rather than /renew,
+	//     also recovers a subscription whose first request was lost
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.client.Done():
+			return
+		case <-ticker.C:
+			s.client.Send(s.request)
+		}
+	}
+}
+
+func (s *subscription) cancel() {
+	// Stop renewing, the console stops sending once the subscription expires
+	//     Other handlers on the same address are left in place
+	s.once.Do(func() {
+		close(s.stop)
+		s.remove()
+	})
+}
+
+func subscribe(client *osc.Client, path string, timeFactor int32, onValue func(msg osc.Message)) (*subscription, error) {
+	// Streams the value of a single parameter
+	//     timeFactor slows the stream to one update every 50ms * timeFactor
+	request := osc.NewMessage("/subscribe")
+	request.AddString(path)
+	request.AddInt(timeFactor)
+	return startSubscription(client, path, request, onValue)
+}
+
+func batchSubscribe(client *osc.Client, alias string, path string, first, last, timeFactor int32, onValues func(words []uint32)) (*subscription, error) {
+	// Streams values first to last of the parameter at path as one blob
+	//     e.g. /-stat/solosw from 0 to 79 for the solo switch of every channelID
+	request := osc.NewMessage("/batchsubscribe")
+	request.AddString(alias)
+	request.AddString(path)
+	request.AddInt(first)
+	request.AddInt(last)
+	request.AddInt(timeFactor)
+	return startSubscription(client, alias, request, blobHandler(onValues))
+}
+
+func formatSubscribe(client *osc.Client, alias string, paths []string, first, last, timeFactor int32, onValues func(words []uint32)) (*subscription, error) {
+	// Streams every path with ** replaced by the indexes first to last
+	//     Values are ordered by index, then by path
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no paths to subscribe to")
+	}
+	request := osc.NewMessage("/formatsubscribe")
+	request.AddString(alias)
+	for _, path := range paths {
+		request.AddString(path)
+	}
+	request.AddInt(first)
+	request.AddInt(last)
+	request.AddInt(timeFactor)
+	return startSubscription(client, alias, request, blobHandler(onValues))
+}
+
+func blobHandler(onValues func(words []uint32)) func(msg osc.Message) {
+	// Decodes the blob of each update, malformed updates are dropped
+	return func(msg osc.Message) {
+		blob, err := msg.ArgBlob(0)
+		if err != nil {
+			return
+		}
+		words, err := decodeBlobWords(blob)
+		if err != nil {
+			return
+		}
+		onValues(words)
+	}
+}
+
+func decodeBlobWords(blob []byte) ([]uint32, error) {
+	// Returns the 32 bit values of a subscription or meter blob
+	//     Use math.Float32frombits for float parameters
+	if len(blob) < 4 {
+		return nil, fmt.Errorf("blob too short")
+	}
+	count := int(int32(binary.LittleEndian.Uint32(blob)))
+	if count < 0 || 4+count*4 > len(blob) {
+		return nil, fmt.Errorf("blob holds %d bytes, not %d values", len(blob)-4, count)
+	}
+	words := make([]uint32, count)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(blob[4+i*4:])
+	}
+	return words, nil
+}
+
+// Every fader but the mains can be streamed with /formatsubscribe,
+// a group at a time, as each kind of channel has its own path
+var faderGroups = []struct {
+	alias     string
+	path      string
+	first     int32 // index of the first fader in its path
+	channelID int   // channelID of the first fader
+	count     int
+}{
+	{"/faders/ch", "/ch/**/mix/fader", 1, 0, 32},
+	{"/faders/auxin", "/auxin/**/mix/fader", 1, 32, 8},
+	{"/faders/fxrtn", "/fxrtn/**/mix/fader", 1, 40, 8},
+	{"/faders/bus", "/bus/**/mix/fader", 1, 48, 16},
+	{"/faders/mtx", "/mtx/**/mix/fader", 1, 64, 6},
+	{"/faders/dca", "/dca/*/fader", 1, 72, 8},
+}
+
+func (m *mixer) watchFaders(client *osc.Client) (stop func(), err error) {
+	// Keeps the level of every fader current from the console
+	//     Call stop to end the subscriptions
+	var subs []*subscription
+	stop = func() {
+		for _, s := range subs {
+			s.cancel()
+		}
+	}
+	for _, g := range faderGroups {
+		g := g
+		s, err := formatSubscribe(client, g.alias, []string{g.path}, g.first, g.first+int32(g.count)-1, 1, func(words []uint32) {
+			for i, w := range words[:min(len(words), g.count)] {
+				m.faders[g.channelID+i].streamLevel(math.Float32frombits(w))
+			}
+		})
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	for _, id := range []int{70, 71} {
+		f := m.faders[id]
+		s, err := subscribe(client, getFaderPath(id), 1, func(msg osc.Message) {
+			if level, err := msg.ArgFloat(0); err == nil {
+				f.streamLevel(level)
+			}
+		})
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return stop, nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+	"github.com/grogersstephen/x32app/x32sim"
+)
+
+// fakeConsole counts the requests it receives and pushes updates back
+type fakeConsole struct {
+	conn     net.PacketConn
+	client   net.Addr
+	requests atomic.Int64
+}
+
+func newFakeConsole(t *testing.T) (*fakeConsole, *osc.Client) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	fc := &fakeConsole{conn: conn}
+	clientConn, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.client = clientConn.LocalAddr()
+	client := osc.NewClient(clientConn)
+	t.Cleanup(func() { client.Close() })
+	go func() {
+		buf := make([]byte, osc.MaxPacketSize)
+		for {
+			if _, _, err := conn.ReadFrom(buf); err != nil {
+				return
+			}
+			fc.requests.Add(1)
+		}
+	}()
+	return fc, client
+}
+
+func (fc *fakeConsole) push(t *testing.T, msg osc.Message) {
+	t.Helper()
+	packet, err := msg.AppendPacket(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fc.conn.WriteTo(packet, fc.client); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func shortRenewal(t *testing.T) {
+	old := renewInterval
+	renewInterval = 20 * time.Millisecond
+	t.Cleanup(func() { renewInterval = old })
+}
+
+func TestSubscriptionRenewal(t *testing.T) {
+	shortRenewal(t)
+	fc, client := newFakeConsole(t)
+	var updates atomic.Int64
+	s, err := subscribe(client, "/ch/01/mix/fader", 1, func(msg osc.Message) {
+		updates.Add(1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The request is sent at once, then renewed
+	eventually(t, "renewals", func() bool {
+		return fc.requests.Load() >= 3
+	})
+	fc.push(t, faderValue(0.5))
+	eventually(t, "an update", func() bool {
+		return updates.Load() == 1
+	})
+
+	// Once cancelled, it is neither renewed nor handled
+	s.cancel()
+	s.cancel()
+	time.Sleep(30 * time.Millisecond)
+	sent := fc.requests.Load()
+	time.Sleep(100 * time.Millisecond)
+	if renewed := fc.requests.Load() - sent; renewed > 0 {
+		t.Errorf("renewed %d times after cancel", renewed)
+	}
+	fc.push(t, faderValue(0.25))
+	time.Sleep(50 * time.Millisecond)
+	if n := updates.Load(); n != 1 {
+		t.Errorf("%d updates handled, want none after cancel", n-1)
+	}
+}
+
+func TestSubscriptionsOnOneAddress(t *testing.T) {
+	// Cancelling one subscription leaves another at the same address
+	fc, client := newFakeConsole(t)
+	var first, second atomic.Int64
+	a, err := subscribe(client, "/ch/01/mix/fader", 1, func(msg osc.Message) {
+		first.Add(1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := subscribe(client, "/ch/01/mix/fader", 4, func(msg osc.Message) {
+		second.Add(1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.cancel()
+
+	fc.push(t, faderValue(0.5))
+	eventually(t, "both subscriptions to update", func() bool {
+		return first.Load() == 1 && second.Load() == 1
+	})
+
+	a.cancel()
+	fc.push(t, faderValue(0.25))
+	eventually(t, "the remaining subscription to update", func() bool {
+		return second.Load() == 2
+	})
+	if n := first.Load(); n != 1 {
+		t.Errorf("cancelled subscription handled %d updates, want 1", n)
+	}
+}
+
+func TestWatchFaders(t *testing.T) {
+	m, console := newTestMixer(t)
+	stop, err := m.watchFaders(m.client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	// A fader streamed by /formatsubscribe, and a main by /subscribe
+	msg := faderValue(0.5)
+	console.Set(msg)
+	main := osc.NewMessage("/main/st/mix/fader")
+	main.AddFloat(0.25)
+	console.Set(main)
+	eventually(t, "levels to be streamed", func() bool {
+		return m.faders[0].lastLevel() == 0.5 && m.faders[70].lastLevel() == 0.25 &&
+			m.faders[0].streamedWithin(time.Second) && m.faders[70].streamedWithin(time.Second)
+	})
+}
+
+// levelLog keeps the last line the level monitor wrote
+type levelLog struct {
+	mu   sync.Mutex
+	last string
+}
+
+func (l *levelLog) log(s string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.last = s
+}
+
+func (l *levelLog) get() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last
+}
+
+func TestMonitorLevels(t *testing.T) {
+	for _, ignore := range []bool{false, true} {
+		// A console which ignores the fader subscriptions
+		//     leaves the monitor asking for levels instead
+		console := x32sim.New()
+		console.IgnoreSubscriptions = ignore
+		m, _ := connectTestMixer(t, console)
+		var out levelLog
+		go m.monitorLevels(out.log)
+
+		console.Set(faderValue(0.5))
+		eventually(t, "the level to be shown", func() bool {
+			return strings.HasSuffix(out.get(), "(0.50)")
+		})
+		if !ignore {
+			eventually(t, "the level to be streamed", func() bool {
+				return m.faders[0].streamedWithin(time.Second)
+			})
+			continue
+		}
+		time.Sleep(150 * time.Millisecond)
+		if m.faders[0].streamedWithin(time.Second) {
+			t.Error("level streamed by a console ignoring subscriptions")
+		}
+	}
+}
@@ -11,10 +11,11 @@ import (
 
 func (f *fader) levelMessage() string {
 	msg := fmt.Sprintf("%s %d", f.name, f.channel)
-	if f.level < 0 {
+	level := f.lastLevel()
+	if level < 0 {
 		return fmt.Sprintf("%s : ??", msg)
 	}
-	return fmt.Sprintf("%s : %.2f", msg, f.level)
+	return fmt.Sprintf("%s : %s (%.2f)", msg, formatDB(faderToDB(level)), level)
 }
 
 func isValidIP(ip string) bool {
@@ -102,6 +103,40 @@ func getFaderPath(ch int) string {
 	return filepath.Join(path, "mix/fader")
 }
 
+func getOnPath(ch int) string {
+	path := getChannelIDPath(ch)
+	if path == "" {
+		return path
+	}
+	// The on switch is the inverse of mute
+	if ch > 71 && ch < 80 { // dca
+		// e.g. "/dca/3/on"
+		return filepath.Join(path, "on")
+	}
+	// e.g. channel 1: /ch/01/mix/on
+	return filepath.Join(path, "mix/on")
+}
+
+func getPanPath(ch int) string {
+	// Matrices, the mono bus and dcas have no pan
+	//     On the main stereo bus it is the balance
+	if ch < 0 || (ch > 63 && ch != 70) {
+		return ""
+	}
+	// e.g. channel 1: /ch/01/mix/pan
+	return filepath.Join(getChannelIDPath(ch), "mix/pan")
+}
+
+func getMuteGroupPath(ch int) string {
+	// Only channels, aux ins, fx returns and buses can join mute groups
+	if ch < 0 || ch > 63 {
+		return ""
+	}
+	path := getChannelIDPath(ch)
+	// e.g. channel 1: /ch/01/grp/mute
+	return filepath.Join(path, "grp/mute")
+}
+
 func getDist(x, y int) int {
 	// Returns the absolute distance between two integers
 	if x < y {
@@ -0,0 +1,428 @@
+// Package x32sim imitates an X32 console on a local UDP port,
+// so the app and its tests can run without the hardware.
+//
+// The simulator answers /info, /xinfo, /node and queries of any parameter
+// it holds, and keeps every value written to it, including names written
+// as node lines to "/".
+// Clients which send /xremote are told of changes made by other clients
+// or through Set, clients which send /meters are streamed meter blobs,
+// and clients which send /subscribe or /formatsubscribe are streamed
+// the values they asked for.
+// Like the console, all expire 10 seconds after the last request
+package x32sim
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// Subscriptions expire this long after they were last requested
+const subscriptionLifetime = 10 * time.Second
+
+// Console is a simulated X32
+type Console struct {
+	Name     string
+	Model    string
+	Firmware string
+	// IgnoreSubscriptions drops /subscribe and /formatsubscribe requests,
+	// to imitate a console which does not answer them. Set it before Start
+	IgnoreSubscriptions bool
+
+	server osc.Server
+	conn   net.PacketConn
+
+	mu      sync.Mutex
+	params  map[string][]byte // encoded messages holding each parameter's value
+	signals []float32         // simulated input level of each channelID
+	remotes map[string]remote
+	meters  map[string]*meterSub
+	subs    map[string]*paramSub
+	done    chan struct{}
+}
+
+type remote struct {
+	peer    osc.Peer
+	expires time.Time
+}
+
+func New() *Console {
+	// Returns a console holding the default value of every parameter
+	//     of the 80 channelIDs used by x32app
+	c := &Console{
+		Name:     "x32sim",
+		Model:    "X32",
+		Firmware: "4.06",
+		params:   make(map[string][]byte),
+		signals:  make([]float32, ChannelCount),
+		remotes:  make(map[string]remote),
+		meters:   make(map[string]*meterSub),
+		subs:     make(map[string]*paramSub),
+		done:     make(chan struct{}),
+	}
+	for id := 0; id < ChannelCount; id++ {
+		c.setDefaults(id)
+	}
+	for group := 1; group <= 6; group++ {
+		c.setInt(fmt.Sprintf("/config/mute/%d", group), 0)
+	}
+	// Stereo links of odd and even neighbours
+	for _, link := range []struct {
+		name  string
+		pairs int
+	}{{"chlink", 16}, {"auxlink", 4}, {"fxlink", 4}, {"buslink", 8}, {"mtxlink", 3}} {
+		for i := 1; i <= link.pairs; i++ {
+			c.setInt(fmt.Sprintf("/config/%s/%d-%d", link.name, 2*i-1, 2*i), 0)
+		}
+	}
+	return c
+}
+
+// ChannelCount is the number of channelIDs, numbered as in x32app:
+// 0 - 31 channels, 32 - 39 aux in, 40 - 47 fx returns, 48 - 63 buses,
+// 64 - 69 matrices, 70 main stereo, 71 main mono, 72 - 79 dcas
+const ChannelCount = 80
+
+func ChannelPath(id int) string {
+	// Returns the address prefix of the channelID, e.g. "/ch/01"
+	switch {
+	case id < 0:
+		return ""
+	case id < 32:
+		return fmt.Sprintf("/ch/%02d", id+1)
+	case id < 40:
+		return fmt.Sprintf("/auxin/%02d", id-31)
+	case id < 48:
+		return fmt.Sprintf("/fxrtn/%02d", id-39)
+	case id < 64:
+		return fmt.Sprintf("/bus/%02d", id-47)
+	case id < 70:
+		return fmt.Sprintf("/mtx/%02d", id-63)
+	case id == 70:
+		return "/main/st"
+	case id == 71:
+		return "/main/m"
+	case id < 80:
+		return fmt.Sprintf("/dca/%d", id-71)
+	default:
+		return ""
+	}
+}
+
+func (c *Console) setDefaults(id int) {
+	path := ChannelPath(id)
+	c.setString(path+"/config/name", "")
+	if id >= 72 {
+		// DCAs have only a fader and an on switch
+		c.setFloat(path+"/fader", 0.75)
+		c.setInt(path+"/on", 1)
+		return
+	}
+	c.setFloat(path+"/mix/fader", 0.75) // 0 dB
+	c.setInt(path+"/mix/on", 1)
+	if id < 64 {
+		// Channels, aux ins, fx returns and buses can join groups
+		c.setInt(path+"/grp/mute", 0) // a bitmask of the six mute groups
+		c.setInt(path+"/grp/dca", 0)  // a bitmask of the eight dcas
+	}
+	if id < 64 || id == 70 {
+		c.setFloat(path+"/mix/pan", 0.5)
+	}
+	if id < 32 {
+		// Channels have a gate and dynamics, both off
+		for _, block := range []string{"/gate", "/dyn"} {
+			c.setInt(path+block+"/on", 0)
+			c.setInt(path+block+"/keysrc", 0)
+			c.setFloat(path+block+"/attack", 10.0/120)
+			c.setFloat(path+block+"/hold", 0.5)
+			c.setFloat(path+block+"/release", 0.5)
+			c.setInt(path+block+"/filter/on", 0)
+			c.setInt(path+block+"/filter/type", 4)
+			c.setFloat(path+block+"/filter/f", 0.5)
+		}
+		c.setInt(path+"/gate/mode", 3)  // GATE
+		c.setFloat(path+"/gate/thr", 0) // -80 dB
+		c.setFloat(path+"/gate/range", 1)
+		c.setInt(path+"/dyn/mode", 0)   // COMP
+		c.setFloat(path+"/dyn/thr", 1)  // 0 dB
+		c.setInt(path+"/dyn/ratio", 6)  // 4:1
+		c.setFloat(path+"/dyn/knee", 0) // hard
+		c.setFloat(path+"/dyn/mgain", 0)
+		c.setFloat(path+"/dyn/mix", 1) // 100%
+	}
+	// Inputs have 4 EQ bands, buses, matrices and mains have 6
+	//     Each starts as a flat parametric band
+	bands := 4
+	if id >= 48 {
+		bands = 6
+	}
+	c.setInt(path+"/eq/on", 0)
+	for band := 1; band <= bands; band++ {
+		eq := fmt.Sprintf("%s/eq/%d", path, band)
+		c.setInt(eq+"/type", 2)
+		c.setFloat(eq+"/f", float32(band)/float32(bands+1))
+		c.setFloat(eq+"/g", 0.5)    // 0 dB
+		c.setFloat(eq+"/q", 0.4648) // Q 2
+	}
+	// Inputs send to the 16 mix buses,
+	//     buses and mains send to the 6 matrices
+	sends := 0
+	switch {
+	case id < 48:
+		sends = 16
+	case id < 64, id == 70, id == 71:
+		sends = 6
+	}
+	for i := 1; i <= sends; i++ {
+		send := fmt.Sprintf("%s/mix/%02d", path, i)
+		c.setFloat(send+"/level", 0)
+		c.setInt(send+"/on", 1)
+		if i%2 == 1 {
+			c.setFloat(send+"/pan", 0.5)
+			c.setInt(send+"/type", 0)
+		}
+	}
+}
+
+func (c *Console) setFloat(address string, value float32) {
+	msg := osc.NewMessage(address)
+	msg.AddFloat(value)
+	c.store(&msg)
+}
+
+func (c *Console) setInt(address string, value int32) {
+	msg := osc.NewMessage(address)
+	msg.AddInt(value)
+	c.store(&msg)
+}
+
+func (c *Console) setString(address string, value string) {
+	msg := osc.NewMessage(address)
+	msg.AddString(value)
+	c.store(&msg)
+}
+
+func (c *Console) store(msg *osc.Message) {
+	byt, err := msg.AppendPacket(nil)
+	if err != nil {
+		return
+	}
+	c.params[string(msg.Address)] = byt
+}
+
+func (c *Console) Start(addr string) error {
+	// Listens on the UDP address, e.g. "127.0.0.1:0" for any free port,
+	//     and serves in the background until Close
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	c.server.Handler = osc.PacketHandlerFunc(c.servePacket)
+	go c.server.Serve(conn)
+	go c.stream()
+	return nil
+}
+
+func (c *Console) Addr() string {
+	// Returns the address the console is listening on, "ip:port"
+	if c.conn == nil {
+		return ""
+	}
+	return c.conn.LocalAddr().String()
+}
+
+func (c *Console) Close() error {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	return c.server.Close()
+}
+
+func (c *Console) Get(address string) (osc.Message, bool) {
+	// Returns the message holding the current value at address
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(address)
+}
+
+func (c *Console) get(address string) (msg osc.Message, ok bool) {
+	byt, ok := c.params[address]
+	if !ok {
+		return msg, false
+	}
+	msg.Packet.Write(byt)
+	if msg.ParseMessage() != nil {
+		return osc.Message{}, false
+	}
+	return msg, true
+}
+
+func (c *Console) Set(msg osc.Message) {
+	// Changes a value as if it were changed on the console's surface
+	//     Every /xremote client is told of the change
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(msg, nil)
+}
+
+func (c *Console) set(msg osc.Message, from net.Addr) {
+	// Stores the value, then tells every /xremote client but the sender
+	c.store(&msg)
+	now := time.Now()
+	for key, r := range c.remotes {
+		if now.After(r.expires) {
+			delete(c.remotes, key)
+			continue
+		}
+		if from != nil && key == from.String() {
+			continue
+		}
+		r.peer.Send(msg)
+	}
+}
+
+func (c *Console) SetSignal(channelID int, level float32) {
+	// Sets the simulated input level of the channel, 1.0 is 0 dBFS
+	//     Meters show it before the fader, and scaled by the fader after it
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if channelID >= 0 && channelID < len(c.signals) {
+		c.signals[channelID] = level
+	}
+}
+
+func (c *Console) servePacket(p osc.Packet, from osc.Peer) {
+	var messages []osc.Message
+	switch pkt := p.(type) {
+	case *osc.Message:
+		messages = []osc.Message{*pkt}
+	case *osc.Bundle:
+		messages = pkt.Messages()
+	}
+	for _, msg := range messages {
+		c.serveMessage(msg, from)
+	}
+}
+
+func (c *Console) serveMessage(msg osc.Message, from osc.Peer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	address := string(msg.Address)
+	switch address {
+	case "/info":
+		reply := osc.NewMessage("/info")
+		reply.AddString("V2.07")
+		reply.AddString("osc-server")
+		reply.AddString(c.Model)
+		reply.AddString(c.Firmware)
+		from.Send(reply)
+	case "/xinfo":
+		reply := osc.NewMessage("/xinfo")
+		host, _, _ := net.SplitHostPort(c.Addr())
+		reply.AddString(host)
+		reply.AddString(c.Name)
+		reply.AddString(c.Model)
+		reply.AddString(c.Firmware)
+		from.Send(reply)
+	case "/xremote":
+		c.remotes[from.Addr.String()] = remote{
+			peer:    from,
+			expires: time.Now().Add(subscriptionLifetime),
+		}
+	case "/meters":
+		c.subscribeMeters(msg, from)
+	case "/subscribe":
+		if !c.IgnoreSubscriptions {
+			c.subscribe(msg, from)
+		}
+	case "/formatsubscribe":
+		if !c.IgnoreSubscriptions {
+			c.formatSubscribe(msg, from)
+		}
+	case "/node":
+		c.serveNode(msg, from)
+	case "/":
+		c.setNode(msg, from.Addr)
+	default:
+		if len(msg.Arguments) > 0 {
+			c.set(msg, from.Addr)
+			return
+		}
+		// Queries of unknown parameters go unanswered, as on the console
+		if reply, ok := c.get(address); ok {
+			from.Send(reply)
+		}
+	}
+}
+
+func (c *Console) serveNode(msg osc.Message, from osc.Peer) {
+	// Answers /node requests for the config node of a channel,
+	//     the block holding its name: name, icon, color, source
+	path, err := msg.ArgString(0)
+	if err != nil {
+		return
+	}
+	path = "/" + strings.TrimPrefix(path, "/")
+	if !strings.HasSuffix(path, "/config") {
+		return
+	}
+	name, ok := c.get(path + "/name")
+	if !ok {
+		return
+	}
+	s, _ := name.ArgString(0)
+	// The console replies at "node", without a leading slash
+	reply := osc.NewMessage("node")
+	reply.AddString(fmt.Sprintf("%s %q 1 YE 1\n", path, s))
+	from.Send(reply)
+}
+
+func (c *Console) setNode(msg osc.Message, from net.Addr) {
+	// Applies a node line written to "/", e.g. /ch/01/config "Kick" 1 RD 1
+	//     Only the name of a config node is kept, the one config value
+	//     the simulator holds, and values left off the line are unchanged
+	text, err := msg.ArgString(0)
+	if err != nil {
+		return
+	}
+	path, values, _ := strings.Cut(strings.TrimRight(text, "\n\x00"), " ")
+	if !strings.HasSuffix(path, "/config") {
+		return
+	}
+	if _, ok := c.get(path + "/name"); !ok {
+		return
+	}
+	name, ok := firstNodeValue(values)
+	if !ok {
+		return
+	}
+	update := osc.NewMessage(path + "/name")
+	update.AddString(name)
+	c.set(update, from)
+}
+
+func firstNodeValue(values string) (string, bool) {
+	// Returns the first of the values of a node line, unquoted
+	values = strings.TrimLeft(values, " ")
+	if values == "" {
+		return "", false
+	}
+	if values[0] != '"' {
+		v, _, _ := strings.Cut(values, " ")
+		return v, true
+	}
+	quoted, err := strconv.QuotedPrefix(values)
+	if err != nil {
+		return "", false
+	}
+	v, err := strconv.Unquote(quoted)
+	return v, err == nil
+}
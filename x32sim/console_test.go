@@ -0,0 +1,278 @@
+package x32sim
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+func startConsole(t *testing.T) *Console {
+	c := New()
+	if err := c.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func dial(t *testing.T, c *Console) *osc.Client {
+	conn, err := net.Dial("udp", c.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := osc.NewClient(conn)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func inquire(t *testing.T, client *osc.Client, address string) osc.Message {
+	t.Helper()
+	reply, err := client.Inquire(context.Background(), osc.NewMessage(address), osc.InquireOptions{})
+	if err != nil {
+		t.Fatalf("%s: %v", address, err)
+	}
+	return reply
+}
+
+func TestInfo(t *testing.T) {
+	c := startConsole(t)
+	client := dial(t, c)
+
+	reply := inquire(t, client, "/info")
+	if model, _ := reply.ArgString(2); model != "X32" {
+		t.Errorf("/info model = %q, want X32", model)
+	}
+	reply = inquire(t, client, "/xinfo")
+	if name, _ := reply.ArgString(1); name != "x32sim" {
+		t.Errorf("/xinfo name = %q, want x32sim", name)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	c := startConsole(t)
+	client := dial(t, c)
+
+	for _, tc := range []struct {
+		address string
+		want    float32
+	}{
+		{"/ch/01/mix/fader", 0.75},
+		{"/auxin/08/mix/fader", 0.75},
+		{"/main/st/mix/fader", 0.75},
+		{"/dca/8/fader", 0.75},
+		{"/ch/32/mix/16/level", 0},
+		{"/bus/16/mix/06/level", 0},
+	} {
+		reply := inquire(t, client, tc.address)
+		got, err := reply.ArgFloat(0)
+		if err != nil || got != tc.want {
+			t.Errorf("%s = %v, %v; want %v", tc.address, got, err, tc.want)
+		}
+	}
+	reply := inquire(t, client, "/dca/1/on")
+	on, err := reply.ArgInt(0)
+	if err != nil || on != 1 {
+		t.Errorf("/dca/1/on = %v, %v; want 1", on, err)
+	}
+}
+
+func TestSetAndQuery(t *testing.T) {
+	c := startConsole(t)
+	client := dial(t, c)
+
+	msg := osc.NewMessage("/ch/05/config/name")
+	msg.AddString("Snare")
+	if err := client.Send(msg); err != nil {
+		t.Fatal(err)
+	}
+	reply := inquire(t, client, "/ch/05/config/name")
+	name, _ := reply.ArgString(0)
+	if name != "Snare" {
+		t.Errorf("name = %q, want Snare", name)
+	}
+
+	// As on the console, the reply to /node is at "node"
+	nodes := make(chan osc.Message, 1)
+	remove := client.HandleFunc("node", func(msg osc.Message) { nodes <- msg })
+	defer remove()
+	request := osc.NewMessage("/node")
+	request.AddString("ch/05/config")
+	if err := client.Send(request); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case reply := <-nodes:
+		if text, _ := reply.ArgString(0); text != "/ch/05/config \"Snare\" 1 YE 1\n" {
+			t.Errorf("node = %q", text)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no reply to /node")
+	}
+
+	// A node line written to "/" sets the name
+	for _, tc := range []struct {
+		line string
+		want string
+	}{
+		{`/ch/05/config "Lead Vox" 1 YE 1`, "Lead Vox"},
+		{"/ch/05/config Kick\n", "Kick"},
+		{`/ch/05/config "" 1 YE 1`, ""},
+	} {
+		write := osc.NewMessage("/")
+		write.AddString(tc.line)
+		if err := client.Send(write); err != nil {
+			t.Fatal(err)
+		}
+		reply := inquire(t, client, "/ch/05/config/name")
+		if name, _ := reply.ArgString(0); name != tc.want {
+			t.Errorf("after %q name = %q, want %q", tc.line, name, tc.want)
+		}
+	}
+}
+
+func TestUnknownQuery(t *testing.T) {
+	c := startConsole(t)
+	client := dial(t, c)
+
+	_, err := client.Inquire(context.Background(), osc.NewMessage("/no/such/thing"),
+		osc.InquireOptions{Timeout: 50 * time.Millisecond})
+	if !errors.Is(err, osc.ErrNoReply) {
+		t.Errorf("err = %v, want ErrNoReply", err)
+	}
+}
+
+func TestXremote(t *testing.T) {
+	c := startConsole(t)
+	watcher := dial(t, c)
+	changer := dial(t, c)
+
+	changes := make(chan osc.Message, 8)
+	watcher.HandleFunc("/ch/*/mix/fader", func(msg osc.Message) { changes <- msg })
+	if err := watcher.Send(osc.NewMessage("/xremote")); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure /xremote arrived before the change
+	inquire(t, watcher, "/info")
+
+	msg := osc.NewMessage("/ch/02/mix/fader")
+	msg.AddFloat(0.5)
+	changer.Send(msg)
+
+	select {
+	case got := <-changes:
+		if level, _ := got.ArgFloat(0); string(got.Address) != "/ch/02/mix/fader" || level != 0.5 {
+			t.Errorf("got %s", got.Format())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no change pushed to the /xremote client")
+	}
+
+	// Surface changes are pushed too
+	msg = osc.NewMessage("/ch/03/mix/fader")
+	msg.AddFloat(0.25)
+	c.Set(msg)
+	select {
+	case got := <-changes:
+		if string(got.Address) != "/ch/03/mix/fader" {
+			t.Errorf("got %s", got.Format())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no surface change pushed to the /xremote client")
+	}
+}
+
+func TestMeters(t *testing.T) {
+	c := startConsole(t)
+	client := dial(t, c)
+	c.SetSignal(0, 0.5)
+
+	blobs := make(chan []byte, 4)
+	client.HandleFunc("/meters/0", func(msg osc.Message) {
+		if blob, err := msg.ArgBlob(0); err == nil {
+			select {
+			case blobs <- blob:
+			default:
+			}
+		}
+	})
+	request := osc.NewMessage("/meters")
+	request.AddString("/meters/0")
+	client.Send(request)
+
+	select {
+	case blob := <-blobs:
+		if len(blob) != 4+70*4 {
+			t.Fatalf("blob of %d bytes, want %d", len(blob), 4+70*4)
+		}
+		if got := meterBlob(c.meterValues(0)); string(got[:8]) != string(blob[:8]) {
+			t.Errorf("blob starts % x, want % x", blob[:8], got[:8])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no meters")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	c := startConsole(t)
+	client := dial(t, c)
+	msg := osc.NewMessage("/ch/02/mix/fader")
+	msg.AddFloat(0.5)
+	c.Set(msg)
+
+	blobs := make(chan []byte, 4)
+	client.HandleFunc("/faders", func(msg osc.Message) {
+		if blob, err := msg.ArgBlob(0); err == nil {
+			select {
+			case blobs <- blob:
+			default:
+			}
+		}
+	})
+	request := osc.NewMessage("/formatsubscribe")
+	request.AddString("/faders")
+	request.AddString("/ch/**/mix/fader")
+	request.AddString("/ch/**/mix/on")
+	request.AddInt(1)
+	request.AddInt(3)
+	request.AddInt(1)
+	client.Send(request)
+
+	select {
+	case blob := <-blobs:
+		// Ordered by index, then by path
+		if len(blob) != 4+6*4 || binary.LittleEndian.Uint32(blob) != 6 {
+			t.Fatalf("blob of %d bytes, want 6 values", len(blob))
+		}
+		if level := math.Float32frombits(binary.LittleEndian.Uint32(blob[4+2*4:])); level != 0.5 {
+			t.Errorf("/ch/02/mix/fader = %v, want 0.5", level)
+		}
+		if on := binary.LittleEndian.Uint32(blob[4+3*4:]); on != 1 {
+			t.Errorf("/ch/02/mix/on = %v, want 1", on)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no /formatsubscribe update")
+	}
+
+	// /subscribe sends the parameter at its own address
+	updates := make(chan osc.Message, 4)
+	client.HandleFunc("/main/st/mix/fader", func(msg osc.Message) {
+		select {
+		case updates <- msg:
+		default:
+		}
+	})
+	request = osc.NewMessage("/subscribe")
+	request.AddString("/main/st/mix/fader")
+	client.Send(request)
+	select {
+	case <-updates:
+	case <-time.After(time.Second):
+		t.Fatal("no /subscribe update")
+	}
+}
@@ -0,0 +1,139 @@
+package x32sim
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// Meters and subscriptions are sent at most every 50ms,
+// slowed by the request's time factor
+const streamInterval = 50 * time.Millisecond
+
+// Number of values in each meter bank, as the console sends them
+var meterBankSizes = [16]int{70, 96, 49, 22, 82, 27, 4, 16, 6, 32, 32, 5, 4, 48, 0, 0}
+
+type meterSub struct {
+	peer    osc.Peer
+	bank    int
+	every   int // send every n ticks
+	tick    int
+	expires time.Time
+}
+
+func (c *Console) subscribeMeters(msg osc.Message, from osc.Peer) {
+	// /meters ,s[i...] /meters/N [bank arguments] [time factor]
+	address, err := msg.ArgString(0)
+	if err != nil || !strings.HasPrefix(address, "/meters/") {
+		return
+	}
+	bank, err := strconv.Atoi(strings.TrimPrefix(address, "/meters/"))
+	if err != nil || bank < 0 || bank >= len(meterBankSizes) {
+		return
+	}
+	// Only bank 6 takes an argument of its own, the channel
+	bankArgs := 0
+	if bank == 6 {
+		bankArgs = 1
+	}
+	every := 1
+	if tf, err := msg.ArgInt(1 + bankArgs); err == nil && tf > 1 {
+		every = int(tf)
+	}
+	key := fmt.Sprintf("%s %d", from.Addr, bank)
+	c.meters[key] = &meterSub{
+		peer:    from,
+		bank:    bank,
+		every:   every,
+		expires: time.Now().Add(subscriptionLifetime),
+	}
+}
+
+func (c *Console) stream() {
+	// Sends meters and subscribed values until the console is closed
+	ticker := time.NewTicker(streamInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+		c.mu.Lock()
+		now := time.Now()
+		for key, s := range c.meters {
+			if now.After(s.expires) {
+				delete(c.meters, key)
+				continue
+			}
+			s.tick++
+			if s.tick%s.every != 0 {
+				continue
+			}
+			msg := osc.NewMessage(fmt.Sprintf("/meters/%d", s.bank))
+			msg.AddBlob(meterBlob(c.meterValues(s.bank)))
+			s.peer.Send(msg)
+		}
+		c.sendSubs(now)
+		c.mu.Unlock()
+	}
+}
+
+func (c *Console) meterValues(bank int) []float32 {
+	// Returns the values of the bank
+	//     Banks other than 0 - 2 are sent as silence
+	size := meterBankSizes[bank]
+	values := make([]float32, size)
+	switch bank {
+	case 0:
+		// Post fader level of channelIDs 0 - 69
+		for id := range values {
+			values[id] = c.postFader(id)
+		}
+	case 1:
+		// Pre fader level of the 32 channels, then no gain reduction
+		copy(values, c.signals[:32])
+		for i := 32; i < size; i++ {
+			values[i] = 1
+		}
+	case 2:
+		// Buses, matrices, main L, R and mono, then no gain reduction
+		for i := 0; i < 22; i++ {
+			values[i] = c.postFader(48 + i)
+		}
+		values[22] = c.postFader(70)
+		values[23] = c.postFader(70)
+		values[24] = c.postFader(71)
+		for i := 25; i < size; i++ {
+			values[i] = 1
+		}
+	}
+	return values
+}
+
+func (c *Console) postFader(id int) float32 {
+	// The signal scaled by the fader, 0.75 passing it unchanged
+	msg, ok := c.get(ChannelPath(id) + "/mix/fader")
+	if !ok {
+		return 0
+	}
+	fader, err := msg.ArgFloat(0)
+	if err != nil {
+		return 0
+	}
+	return c.signals[id] * fader / 0.75
+}
+
+func meterBlob(values []float32) []byte {
+	// An int32 count then the values, all little endian
+	blob := binary.LittleEndian.AppendUint32(nil, uint32(len(values)))
+	for _, v := range values {
+		blob = binary.LittleEndian.AppendUint32(blob, math.Float32bits(v))
+	}
+	return blob
+}
@@ -0,0 +1,147 @@
+package x32sim
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// A paramSub streams parameter values to a client, as /subscribe and
+// /formatsubscribe do on the console, every 50ms slowed by the time factor.
+// A /subscribe sends the single parameter at its own address,
+// and a /formatsubscribe sends every path with ** replaced by each index
+// from first to last, as a blob of 32 bit values at the client's alias
+type paramSub struct {
+	peer    osc.Peer
+	alias   string
+	paths   []string
+	first   int
+	last    int
+	single  bool // /subscribe
+	every   int  // send every n ticks
+	tick    int
+	expires time.Time
+}
+
+func (c *Console) subscribe(msg osc.Message, from osc.Peer) {
+	// /subscribe ,s[i] path [time factor]
+	path, err := msg.ArgString(0)
+	if err != nil || !strings.HasPrefix(path, "/") {
+		return
+	}
+	c.addSub(from, &paramSub{
+		alias:  path,
+		paths:  []string{path},
+		single: true,
+		every:  timeFactor(msg, 1),
+	})
+}
+
+func (c *Console) formatSubscribe(msg osc.Message, from osc.Peer) {
+	// /formatsubscribe ,ss...iii alias path... first last time factor
+	alias, err := msg.ArgString(0)
+	if err != nil {
+		return
+	}
+	var paths []string
+	i := 1
+	for ; i < len(msg.Arguments); i++ {
+		path, err := msg.ArgString(i)
+		if err != nil {
+			break
+		}
+		paths = append(paths, path)
+	}
+	first, err := msg.ArgInt(i)
+	if err != nil {
+		return
+	}
+	last, err := msg.ArgInt(i + 1)
+	if err != nil || len(paths) == 0 || last < first {
+		return
+	}
+	c.addSub(from, &paramSub{
+		alias: alias,
+		paths: paths,
+		first: int(first),
+		last:  int(last),
+		every: timeFactor(msg, i+2),
+	})
+}
+
+func timeFactor(msg osc.Message, i int) int {
+	if tf, err := msg.ArgInt(i); err == nil && tf > 1 {
+		return int(tf)
+	}
+	return 1
+}
+
+func (c *Console) addSub(from osc.Peer, s *paramSub) {
+	// A repeated request renews the subscription at the same alias
+	s.peer = from
+	s.expires = time.Now().Add(subscriptionLifetime)
+	c.subs[fmt.Sprintf("%s %s", from.Addr, s.alias)] = s
+}
+
+func (c *Console) sendSubs(now time.Time) {
+	for key, s := range c.subs {
+		if now.After(s.expires) {
+			delete(c.subs, key)
+			continue
+		}
+		s.tick++
+		if s.tick%s.every != 0 {
+			continue
+		}
+		if s.single {
+			if msg, ok := c.get(s.alias); ok {
+				s.peer.Send(msg)
+			}
+			continue
+		}
+		msg := osc.NewMessage(s.alias)
+		msg.AddBlob(c.subBlob(s))
+		s.peer.Send(msg)
+	}
+}
+
+func (c *Console) subBlob(s *paramSub) []byte {
+	// The values ordered by index, then by path
+	//     Parameters the simulator does not hold are sent as 0
+	count := (s.last - s.first + 1) * len(s.paths)
+	blob := binary.LittleEndian.AppendUint32(nil, uint32(count))
+	for index := s.first; index <= s.last; index++ {
+		for _, path := range s.paths {
+			blob = binary.LittleEndian.AppendUint32(blob, c.word(indexPath(path, index)))
+		}
+	}
+	return blob
+}
+
+func indexPath(path string, index int) string {
+	// ** is a two digit index, as in /ch/**/mix/fader
+	//     and * a single digit one, as in /dca/*/fader
+	if strings.Contains(path, "**") {
+		return strings.Replace(path, "**", fmt.Sprintf("%02d", index), 1)
+	}
+	return strings.Replace(path, "*", fmt.Sprint(index), 1)
+}
+
+func (c *Console) word(address string) uint32 {
+	// The value at address as 32 bits, float or int
+	msg, ok := c.get(address)
+	if !ok {
+		return 0
+	}
+	if f, err := msg.ArgFloat(0); err == nil {
+		return math.Float32bits(f)
+	}
+	if i, err := msg.ArgInt(0); err == nil {
+		return uint32(i)
+	}
+	return 0
+}
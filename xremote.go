@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+// X32 subscriptions such as /xremote and /meters expire after 10 seconds,
+// so they are renewed well before then.
+// A var so tests can shorten it
+var renewInterval = 8 * time.Second
+
+// The mirror is trusted only while the console has been heard from
+// within this long, as a renewed /xremote gets no answer once the
+// console is unplugged or stops pushing
+const mirrorTimeout = 10 * time.Second
+
+// The blocks of the console the app reads and writes, which the mirror
+// keeps. Handling only these, rather than every address, lets the client
+// drop meters and other traffic nobody wants without copying it
+var mirroredAddresses = []string{
+	"/ch//*", "/auxin//*", "/fxrtn//*", "/bus//*", "/mtx//*",
+	"/main//*", "/dca//*", "/config//*", "node", "/node",
+}
+
+// consoleMirror holds the last known value of every console parameter
+// the app has read, written, or been told about through /xremote.
+// While /xremote is running the console pushes every change made on
+// its surface or by other clients, so the mirror stays current
+type consoleMirror struct {
+	mu        sync.RWMutex
+	entries   map[string]*mirrorEntry
+	live      bool        // true while /xremote is being renewed
+	lastHeard time.Time   // when the console last sent us anything
+	view      osc.Message // reused to parse the packets we send
+}
+
+// Each value is kept encoded, in a buffer which is reused when it changes,
+// so the steady stream of packets from a fade does not allocate
+type mirrorEntry struct {
+	packet    []byte
+	view      osc.Message // parsed in place from packet
+	changedAt time.Time
+}
+
+func newConsoleMirror() *consoleMirror {
+	return &consoleMirror{
+		entries: make(map[string]*mirrorEntry),
+	}
+}
+
+func (cm *consoleMirror) apply(msg osc.Message) {
+	// Records the value carried by msg
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.applyLocked(&msg)
+}
+
+func (cm *consoleMirror) applyLocked(msg *osc.Message) {
+	// Queries carry no value, and blobs hold meters or subscriptions
+	//     which are too frequent to keep
+	if len(msg.Arguments) == 0 || msg.Arguments[0].TypeTag == 'b' {
+		return
+	}
+	// Node text, a reply to /node or a write to "/", covers a whole block
+	switch string(msg.Address) {
+	case "node", "/node", "/":
+		if text, err := msg.ArgString(0); err == nil {
+			if n, err := parseNode(text); err == nil {
+				cm.applyNode(n)
+			}
+		}
+		return
+	}
+	entry, known := cm.entries[string(msg.Address)]
+	if known && sameArguments(entry.view, *msg) {
+		return
+	}
+	if !known {
+		// The first value seen is not a change
+		//     so a fader which was just read is not taken to be moving
+		entry = &mirrorEntry{}
+		cm.entries[string(msg.Address)] = entry
+	} else {
+		entry.changedAt = time.Now()
+	}
+	packet, err := msg.AppendPacket(entry.packet[:0])
+	if err != nil || entry.view.ParseView(packet) != nil {
+		delete(cm.entries, string(msg.Address))
+		return
+	}
+	entry.packet = packet
+}
+
+func (cm *consoleMirror) applyNode(n consoleNode) {
+	// Node values are in display units, so only a name can be kept exactly
+	//     Every other value in the block is forgotten,
+	//     to be read again from the console when next needed
+	prefix := n.path + "/"
+	for address := range cm.entries {
+		if strings.HasPrefix(address, prefix) {
+			delete(cm.entries, address)
+		}
+	}
+	if !strings.HasSuffix(n.path, "/config") {
+		return
+	}
+	// The name is the first value of every config node
+	if name, err := n.value(0); err == nil {
+		msg := osc.NewMessage(n.path + "/name")
+		msg.AddString(name)
+		cm.applyLocked(&msg)
+	}
+}
+
+func (cm *consoleMirror) applyPacket(packet []byte) {
+	// Records the values carried by a packet we send
+	//     A message is parsed in place and copied only if its value changed
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.view.ParseView(packet) == nil {
+		cm.applyLocked(&cm.view)
+		return
+	}
+	p, err := osc.ParsePacket(packet)
+	if err != nil {
+		return
+	}
+	if bundle, ok := p.(*osc.Bundle); ok {
+		for _, msg := range bundle.Messages() {
+			cm.applyLocked(&msg)
+		}
+	}
+}
+
+func sameArguments(a, b osc.Message) bool {
+	if len(a.Arguments) != len(b.Arguments) {
+		return false
+	}
+	for i := range a.Arguments {
+		if a.Arguments[i].TypeTag != b.Arguments[i].TypeTag ||
+			!bytes.Equal(a.Arguments[i].Data, b.Arguments[i].Data) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cm *consoleMirror) get(address string) (osc.Message, bool) {
+	// Returns the mirrored value at address
+	//     Values are only trusted while /xremote keeps them current
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	if !cm.current() {
+		return osc.Message{}, false
+	}
+	entry, ok := cm.entries[address]
+	if !ok {
+		return osc.Message{}, false
+	}
+	// A copy of its own, as the entry's buffer is reused
+	var msg osc.Message
+	msg.Packet.Write(entry.packet)
+	if msg.ParseMessage() != nil {
+		return osc.Message{}, false
+	}
+	return msg, true
+}
+
+func (cm *consoleMirror) changedWithin(address string, d time.Duration) (changed bool, ok bool) {
+	// Reports whether the value at address changed within d
+	//     ok is false when the mirror cannot tell
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	if !cm.current() {
+		return false, false
+	}
+	entry, known := cm.entries[address]
+	if !known {
+		return false, false
+	}
+	return time.Since(entry.changedAt) < d, true
+}
+
+func (cm *consoleMirror) current() bool {
+	// Reports whether the mirror can be trusted, cm.mu must be held
+	return cm.live && time.Since(cm.lastHeard) < mirrorTimeout
+}
+
+func (cm *consoleMirror) setLive(live bool) {
+	// Going live or offline clears the mirror
+	//     as changes may have been missed in between
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.live = live
+	cm.lastHeard = time.Now()
+	cm.entries = make(map[string]*mirrorEntry)
+}
+
+func (cm *consoleMirror) heard() {
+	// Notes that the console sent something, a push or a reply
+	//     If it had gone quiet for too long, changes may have been missed,
+	//     so the mirror starts again from empty
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if time.Since(cm.lastHeard) >= mirrorTimeout {
+		cm.entries = make(map[string]*mirrorEntry)
+	}
+	cm.lastHeard = time.Now()
+}
+
+// mirrorConn applies every packet we send to the mirror,
+// as the console does not echo our own changes back through /xremote,
+// and tells the mirror whenever the console is heard from
+type mirrorConn struct {
+	net.Conn
+	mirror *consoleMirror
+}
+
+func (mc *mirrorConn) Write(byt []byte) (int, error) {
+	n, err := mc.Conn.Write(byt)
+	if err == nil {
+		mc.mirror.applyPacket(byt)
+	}
+	return n, err
+}
+
+func (mc *mirrorConn) Read(byt []byte) (int, error) {
+	n, err := mc.Conn.Read(byt)
+	if err == nil {
+		mc.mirror.heard()
+	}
+	return n, err
+}
+
+func (m *mixer) startRemote(client *osc.Client) (stop func()) {
+	// Asks the console to push every parameter change to us
+	//     and applies each one to the mirror, renewing until stopped
+	removeHandlers := make([]func(), len(mirroredAddresses))
+	for i, address := range mirroredAddresses {
+		removeHandlers[i] = client.HandleFunc(address, m.mirror.apply)
+	}
+	m.mirror.setLive(true)
+
+	// The first request is sent before returning
+	//     so the console has it before anything else we send
+	client.Send(osc.NewMessage("/xremote"))
+	done := make(chan struct{})
+	interval := renewInterval
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-client.Done():
+				m.mirror.setLive(false)
+				return
+			case <-ticker.C:
+				client.Send(osc.NewMessage("/xremote"))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			for _, remove := range removeHandlers {
+				remove()
+			}
+			m.mirror.setLive(false)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grogersstephen/x32app/osc"
+)
+
+func faderValue(level float32) osc.Message {
+	msg := osc.NewMessage("/ch/01/mix/fader")
+	msg.AddFloat(level)
+	return msg
+}
+
+func TestMirrorExpires(t *testing.T) {
+	cm := newConsoleMirror()
+	cm.apply(faderValue(0.5))
+	if _, ok := cm.get("/ch/01/mix/fader"); ok {
+		t.Error("mirror served a value before going live")
+	}
+
+	cm.setLive(true)
+	cm.apply(faderValue(0.5))
+	if _, ok := cm.get("/ch/01/mix/fader"); !ok {
+		t.Fatal("live mirror has no value")
+	}
+
+	// Nothing heard from the console for longer than the timeout
+	cm.mu.Lock()
+	cm.lastHeard = time.Now().Add(-mirrorTimeout)
+	cm.mu.Unlock()
+	if _, ok := cm.get("/ch/01/mix/fader"); ok {
+		t.Error("mirror served a value after the console went quiet")
+	}
+	if _, ok := cm.changedWithin("/ch/01/mix/fader", time.Second); ok {
+		t.Error("mirror reported motion after the console went quiet")
+	}
+
+	// Hearing from it again starts over, as changes may have been missed
+	cm.heard()
+	if _, ok := cm.get("/ch/01/mix/fader"); ok {
+		t.Error("mirror kept a value from before the console went quiet")
+	}
+	cm.apply(faderValue(0.25))
+	msg, ok := cm.get("/ch/01/mix/fader")
+	if level, err := msg.ArgFloat(0); !ok || err != nil || level != 0.25 {
+		t.Errorf("mirror = %v, %v, %v; want 0.25", level, ok, err)
+	}
+}
+
+func TestMirrorFallsBackToQuery(t *testing.T) {
+	// Once the mirror is stale, levels come from the console
+	m, console := newTestMixer(t)
+	// A round trip, so the console has had our /xremote
+	if _, err := m.getLevel(0); err != nil {
+		t.Fatal(err)
+	}
+	console.Set(faderValue(0.5))
+	eventually(t, "the change to reach the mirror", func() bool {
+		msg, ok := m.mirror.get("/ch/01/mix/fader")
+		level, _ := msg.ArgFloat(0)
+		return ok && level == 0.5
+	})
+	// A change the mirror does not hear of
+	m.mirror.apply(faderValue(0.9))
+	m.mirror.mu.Lock()
+	m.mirror.lastHeard = time.Now().Add(-mirrorTimeout)
+	m.mirror.mu.Unlock()
+
+	level, err := m.getLevel(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level != 0.5 {
+		t.Errorf("level = %v, want the console's 0.5", level)
+	}
+}
+
+func TestMirroredAddresses(t *testing.T) {
+	mirrored := func(address string) bool {
+		for _, pattern := range mirroredAddresses {
+			if osc.Match(pattern, address) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, address := range []string{
+		"/ch/01/mix/fader", "/auxin/08/mix/on", "/fxrtn/01/mix/pan", "/bus/16/config/name",
+		"/mtx/06/mix/fader", "/main/st/mix/fader", "/main/m/mix/on", "/dca/1/fader",
+		"/config/chlink/1-2", "/config/mute/1", "/ch/01/mix/16/level", "/node",
+	} {
+		if !mirrored(address) {
+			t.Errorf("%s is not mirrored", address)
+		}
+	}
+	// Meters arrive every 50ms and are never mirrored
+	for _, address := range []string{"/meters/1", "/xremote", "/info", "/status", "/chx/01"} {
+		if mirrored(address) {
+			t.Errorf("%s is mirrored", address)
+		}
+	}
+}
+
+func fadePackets(n int) [][]byte {
+	// The packets of a fade, each a new level
+	packets := make([][]byte, n)
+	for i := range packets {
+		msg := faderValue(float32(i) / float32(n))
+		packets[i], _ = msg.AppendPacket(nil)
+	}
+	return packets
+}
+
+// discardConn accepts every write
+type discardConn struct {
+	net.Conn
+}
+
+func (discardConn) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func TestMirrorConnAllocs(t *testing.T) {
+	// Sending a fade step updates the mirror without allocating
+	cm := newConsoleMirror()
+	cm.setLive(true)
+	conn := &mirrorConn{Conn: discardConn{}, mirror: cm}
+	packets := fadePackets(100)
+	conn.Write(packets[0])
+	i := 0
+	allocs := testing.AllocsPerRun(100, func() {
+		i = (i + 1) % len(packets)
+		conn.Write(packets[i])
+	})
+	if allocs != 0 {
+		t.Errorf("a fade step made %v allocations, want 0", allocs)
+	}
+	msg, ok := cm.get("/ch/01/mix/fader")
+	want := float32(i) / float32(len(packets))
+	if level, err := msg.ArgFloat(0); !ok || err != nil || level != want {
+		t.Errorf("mirror = %v, %v, %v; want %v", level, ok, err, want)
+	}
+}
+
+func BenchmarkMirrorConnWrite(b *testing.B) {
+	cm := newConsoleMirror()
+	cm.setLive(true)
+	conn := &mirrorConn{Conn: discardConn{}, mirror: cm}
+	packets := fadePackets(256)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn.Write(packets[i%len(packets)])
+	}
+}